@@ -26,6 +26,18 @@ func main() {
 	motor := &OpticalMotor{}
 	adapter := CreateOpticalAdapter(motor)
 	adapter.Drive()
+
+	// 远程适配器：服务端与客户端运行在同一进程中
+	server := CreateMotorServer()
+	server.Register("electric", CreateElectricAdapter(&ElectricMotor{}))
+	if err := server.Listen("tcp", "127.0.0.1:0"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer server.Close()
+	remote := CreateRemoteAdapter("tcp", server.Addr().String(), "electric")
+	defer remote.Close()
+	remote.Drive()
 }
 
 /*
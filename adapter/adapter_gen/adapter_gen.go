@@ -0,0 +1,472 @@
+/*
+	适配器代码生成工具
+	根据目标接口（如 Motor）、适配者类型（如 OpticalMotor）以及方法映射，生成适配器结构体、
+	CreateXxxAdapter 风格的构造函数，以及适配器实现目标接口的编译期断言。
+	用法：
+		go run ./adapter/adapter_gen -dir ./adapter -iface Motor -adaptee OpticalMotor -map Drive=OpticalDrive
+	映射方法的签名不一致时，以 文件:行号 的形式报告错误。
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "目标接口和适配者所在的源码目录")
+	iface := flag.String("iface", "", "目标接口名，如 Motor")
+	adaptee := flag.String("adaptee", "", "适配者类型名，如 OpticalMotor")
+	mapping := flag.String("map", "", "方法映射，如 Drive=OpticalDrive，多个映射用逗号分隔；同名方法可省略")
+	name := flag.String("name", "", "适配器类型名，默认由适配者类型名推导，如 OpticalAdapter")
+	out := flag.String("o", "", "输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if *iface == "" || *adaptee == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	methods, err := ParseMapping(*mapping)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	g, err := CreateGenerator(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	src, errs := g.Generate(*iface, *adaptee, *name, methods)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// 解析方法映射：接口方法名 -> 适配者方法名
+func ParseMapping(s string) (map[string]string, error) {
+	m := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid mapping %q, want Method=AdapteeMethod", pair)
+		}
+		if _, ok := m[kv[0]]; ok {
+			return nil, fmt.Errorf("method %s is mapped more than once", kv[0])
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+// 生成错误：带有源码位置
+type PosError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *PosError) Error() string {
+	if !e.Pos.IsValid() {
+		return e.Msg
+	}
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// 方法：名称、签名以及声明位置
+type method struct {
+	name string
+	typ  *ast.FuncType
+	pos  token.Pos
+	file *ast.File
+}
+
+// 生成器：保存解析后的源码
+type Generator struct {
+	fset    *token.FileSet
+	pkgName string
+	files   []*ast.File
+}
+
+func CreateGenerator(dir string) (*Generator, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: want exactly one package, found %d", dir, len(pkgs))
+	}
+	g := &Generator{fset: fset}
+	for name, pkg := range pkgs {
+		g.pkgName = name
+		names := make([]string, 0, len(pkg.Files))
+		for n := range pkg.Files {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			g.files = append(g.files, pkg.Files[n])
+		}
+	}
+	return g, nil
+}
+
+// 生成适配器源码；出错时返回所有错误
+func (g *Generator) Generate(iface, adaptee, name string, mapping map[string]string) ([]byte, []error) {
+	var errs []error
+	fail := func(pos token.Pos, format string, args ...interface{}) {
+		errs = append(errs, &PosError{Pos: g.fset.Position(pos), Msg: fmt.Sprintf(format, args...)})
+	}
+
+	targets, ifacePos, err := g.interfaceMethods(iface)
+	if err != nil {
+		return nil, []error{err}
+	}
+	adapteePos, ok := g.typePos(adaptee)
+	if !ok {
+		return nil, []error{fmt.Errorf("type %s not found", adaptee)}
+	}
+	sources := g.adapteeMethods(adaptee)
+
+	known := map[string]bool{}
+	for _, t := range targets {
+		known[t.name] = true
+	}
+	for from := range mapping {
+		if !known[from] {
+			fail(ifacePos, "%s has no method %s", iface, from)
+		}
+	}
+
+	imports := map[string]string{}
+	var pairs [][2]*method
+	for _, t := range targets {
+		to, ok := mapping[t.name]
+		if !ok {
+			to = t.name
+		}
+		s, ok := sources[to]
+		if !ok {
+			if to == t.name {
+				fail(t.pos, "method %s.%s is not mapped and %s has no method %s", iface, t.name, adaptee, to)
+			} else {
+				fail(adapteePos, "%s has no method %s (mapped from %s.%s)", adaptee, to, iface, t.name)
+			}
+			continue
+		}
+		want, have := g.signature(t), g.signature(s)
+		if want != have {
+			fail(s.pos, "%s.%s has signature %s, which does not match %s.%s %s",
+				adaptee, s.name, have, iface, t.name, want)
+			continue
+		}
+		g.collectImports(t, imports)
+		pairs = append(pairs, [2]*method{t, s})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if name == "" {
+		name = strings.TrimSuffix(adaptee, iface) + "Adapter"
+	}
+	src, err := g.render(iface, adaptee, name, pairs, imports)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return src, nil
+}
+
+func (g *Generator) interfaceMethods(iface string) ([]*method, token.Pos, error) {
+	for _, f := range g.files {
+		for _, spec := range typeSpecs(f) {
+			if spec.Name.Name != iface {
+				continue
+			}
+			it, ok := spec.Type.(*ast.InterfaceType)
+			if !ok {
+				return nil, spec.Pos(), &PosError{Pos: g.fset.Position(spec.Pos()), Msg: iface + " is not an interface"}
+			}
+			var methods []*method
+			for _, field := range it.Methods.List {
+				ft, ok := field.Type.(*ast.FuncType)
+				if !ok {
+					return nil, spec.Pos(), &PosError{Pos: g.fset.Position(field.Pos()), Msg: "embedded interfaces are not supported"}
+				}
+				for _, n := range field.Names {
+					methods = append(methods, &method{name: n.Name, typ: ft, pos: n.Pos(), file: f})
+				}
+			}
+			return methods, spec.Pos(), nil
+		}
+	}
+	return nil, token.NoPos, fmt.Errorf("interface %s not found", iface)
+}
+
+func (g *Generator) typePos(name string) (token.Pos, bool) {
+	for _, f := range g.files {
+		for _, spec := range typeSpecs(f) {
+			if spec.Name.Name == name {
+				return spec.Pos(), true
+			}
+		}
+	}
+	return token.NoPos, false
+}
+
+func (g *Generator) adapteeMethods(adaptee string) map[string]*method {
+	methods := map[string]*method{}
+	for _, f := range g.files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || len(fd.Recv.List) != 1 {
+				continue
+			}
+			recv := fd.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if id, ok := recv.(*ast.Ident); ok && id.Name == adaptee {
+				methods[fd.Name.Name] = &method{name: fd.Name.Name, typ: fd.Type, pos: fd.Name.Pos(), file: f}
+			}
+		}
+	}
+	return methods
+}
+
+func typeSpecs(f *ast.File) []*ast.TypeSpec {
+	var specs []*ast.TypeSpec
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gd.Specs {
+			specs = append(specs, s.(*ast.TypeSpec))
+		}
+	}
+	return specs
+}
+
+// 忽略参数名的签名，如 func(int, string) error
+func (g *Generator) signature(m *method) string {
+	params := g.types(m.typ.Params)
+	results := g.types(m.typ.Results)
+	sig := "func(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+func (g *Generator) types(fl *ast.FieldList) []string {
+	var types []string
+	if fl == nil {
+		return types
+	}
+	for _, field := range fl.List {
+		t := g.expr(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func (g *Generator) expr(e ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, g.fset, e)
+	return buf.String()
+}
+
+// 收集签名中引用到的包
+func (g *Generator) collectImports(m *method, imports map[string]string) {
+	paths := map[string]string{}
+	for _, spec := range m.file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		paths[name] = path
+	}
+	ast.Inspect(m.typ, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok {
+			if path, ok := paths[id.Name]; ok {
+				imports[id.Name] = path
+			}
+		}
+		return false
+	})
+}
+
+func (g *Generator) render(iface, adaptee, name string, pairs [][2]*method, imports map[string]string) ([]byte, error) {
+	field := lowerFirst(iface)
+	recv := receiver(name, pairs)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by adapter_gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkgName)
+	if len(imports) > 0 {
+		names := make([]string, 0, len(imports))
+		for n := range imports {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		buf.WriteString("import (\n")
+		for _, n := range names {
+			path := imports[n]
+			if path[strings.LastIndex(path, "/")+1:] == n {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", n, path)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	fmt.Fprintf(&buf, "// 适配器：把 %s 适配为 %s\n", adaptee, iface)
+	fmt.Fprintf(&buf, "type %s struct {\n\t%s *%s\n}\n\n", name, field, adaptee)
+	for _, p := range pairs {
+		t, s := p[0], p[1]
+		params, args := g.params(t.typ)
+		results := g.results(t.typ)
+		fmt.Fprintf(&buf, "func (%s *%s) %s(%s)%s {\n\t", recv, name, t.name, params, results)
+		if results != "" {
+			buf.WriteString("return ")
+		}
+		fmt.Fprintf(&buf, "%s.%s.%s(%s)\n}\n\n", recv, field, s.name, args)
+	}
+	fmt.Fprintf(&buf, "func Create%s(%s *%s) *%s {\n\treturn &%s{%s: %s}\n}\n\n", name, field, adaptee, name, name, field, field)
+	fmt.Fprintf(&buf, "var _ %s = (*%s)(nil)\n", iface, name)
+
+	return format.Source(buf.Bytes())
+}
+
+// 接收者名：适配器名的首字母小写，与任何参数名或返回值名冲突时换一个
+func receiver(name string, pairs [][2]*method) string {
+	used := map[string]bool{}
+	for _, p := range pairs {
+		for _, fl := range []*ast.FieldList{p[0].typ.Params, p[0].typ.Results} {
+			if fl == nil {
+				continue
+			}
+			for _, field := range fl.List {
+				for _, n := range field.Names {
+					used[n.Name] = true
+				}
+			}
+		}
+	}
+	base := strings.ToLower(name[:1])
+	if !used[base] {
+		return base
+	}
+	if !used["adapter"] {
+		return "adapter"
+	}
+	for i := 0; ; i++ {
+		if r := fmt.Sprintf("%s%d", base, i); !used[r] {
+			return r
+		}
+	}
+}
+
+// 生成参数列表与调用实参；缺少参数名时自动命名
+func (g *Generator) params(ft *ast.FuncType) (string, string) {
+	var params, args []string
+	i := 0
+	for _, field := range ft.Params.List {
+		typ := g.expr(field.Type)
+		_, variadic := field.Type.(*ast.Ellipsis)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, n := range names {
+			arg := fmt.Sprintf("p%d", i)
+			if n != nil && n.Name != "_" {
+				arg = n.Name
+			}
+			i++
+			params = append(params, arg+" "+typ)
+			if variadic {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	return strings.Join(params, ", "), strings.Join(args, ", ")
+}
+
+// 生成返回值列表，保留原有的返回值名
+func (g *Generator) results(ft *ast.FuncType) string {
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return ""
+	}
+	list := ft.Results.List
+	if len(list) == 1 && len(list[0].Names) == 0 {
+		return " " + g.expr(list[0].Type)
+	}
+	var fields []string
+	for _, field := range list {
+		typ := g.expr(field.Type)
+		if len(field.Names) == 0 {
+			fields = append(fields, typ)
+			continue
+		}
+		var names []string
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		fields = append(fields, strings.Join(names, ", ")+" "+typ)
+	}
+	return " (" + strings.Join(fields, ", ") + ")"
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
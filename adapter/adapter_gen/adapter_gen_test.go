@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "用生成的结果更新 testdata 中的 golden 文件")
+
+// 生成的适配器与 golden 文件一致：映射和同名方法、别名导入、可变参数、返回值名、缺少的参数名、接收者名冲突
+func TestGenerateGolden(t *testing.T) {
+	g, err := CreateGenerator(filepath.Join("testdata", "player"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := ParseMapping("Play=Start, Stop=Halt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, errs := g.Generate("Player", "LegacyPlayer", "", mapping)
+	if len(errs) > 0 {
+		t.Fatalf("Generate() errors: %v", errs)
+	}
+
+	golden := filepath.Join("testdata", "player_adapter.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated adapter differs from %s:\n%s", golden, src)
+	}
+}
+
+// 签名不一致时以 文件:行号 的形式报告适配者方法的位置
+func TestGenerateSignatureMismatch(t *testing.T) {
+	g, err := CreateGenerator(filepath.Join("testdata", "mismatch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join("testdata", "mismatch", "mismatch.go")
+	tests := []struct {
+		mapping map[string]string
+		want    string
+	}{
+		{nil, file + ":9:22: SteamMotor.Drive has signature func(), which does not match Motor.Drive func(int) error"},
+		{map[string]string{"Drive": "Boil"}, file + ":11:22: SteamMotor.Boil has signature func(int64) error, which does not match Motor.Drive func(int) error"},
+	}
+	for _, tt := range tests {
+		src, errs := g.Generate("Motor", "SteamMotor", "", tt.mapping)
+		if src != nil || len(errs) != 1 || errs[0].Error() != tt.want {
+			t.Errorf("Generate(%v) errors = %v, want %q", tt.mapping, errs, tt.want)
+		}
+	}
+}
+
+func TestGenerateMappingErrors(t *testing.T) {
+	g, err := CreateGenerator(filepath.Join("testdata", "mismatch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := g.Generate("Motor", "SteamMotor", "", map[string]string{"Drive": "Run", "Fly": "Boil"})
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{"Motor has no method Fly", "SteamMotor has no method Run (mapped from Motor.Drive)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("errors %q, want %q", got, want)
+		}
+	}
+
+	if _, err := ParseMapping("Drive=Run,Drive=Boil"); err == nil {
+		t.Error("ParseMapping accepted a method mapped twice")
+	}
+}
@@ -0,0 +1,13 @@
+package mismatch
+
+type Motor interface {
+	Drive(speed int) error
+}
+
+type SteamMotor struct{}
+
+func (s *SteamMotor) Drive() {}
+
+func (s *SteamMotor) Boil(speed int64) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package player
+
+import (
+	"context"
+	stdio "io"
+	"time"
+)
+
+// 目标接口
+type Player interface {
+	Play(ctx context.Context, name string, at time.Duration) error
+	Load(stdio.Reader) (n int, err error)
+	Mix(l string, tracks ...string) string
+	Stop()
+}
+
+// 适配者
+type LegacyPlayer struct{}
+
+func (l *LegacyPlayer) Start(ctx context.Context, track string, offset time.Duration) error {
+	return nil
+}
+
+func (l *LegacyPlayer) Load(r stdio.Reader) (int, error) {
+	return 0, nil
+}
+
+func (l LegacyPlayer) Mix(first string, rest ...string) string {
+	return first
+}
+
+func (l *LegacyPlayer) Halt() {}
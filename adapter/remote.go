@@ -0,0 +1,331 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+/*
+	发动机控制器运行在单独的进程里，客户端无法直接调用适配者的方法。
+	发动机服务（MotorServer）通过 net/rpc 把任意 Motor 暴露在 Unix 或 TCP 回环套接字上，
+	远程适配器（RemoteAdapter）实现 Motor 接口，把 Drive() 转化为对服务端的远程调用，
+	这个过程对客户类同样是透明的。
+*/
+
+// 调用超时：服务端可能已经执行了这次调用，所以不会重试
+var ErrCallTimeout = errors.New("remote call timed out")
+
+var ErrServerClosed = errors.New("motor server is closed")
+
+const (
+	defaultRemoteTimeout = 3 * time.Second // 默认的连接与调用超时
+	defaultRemoteRetries = 1               // 连接断开后默认的重连次数
+)
+
+// 远程调用参数
+type DriveArgs struct {
+	Name string // 发动机名称
+}
+
+// 远程调用结果
+type DriveReply struct {
+}
+
+// 远程错误：服务端返回的错误
+type RemoteError struct {
+	Msg string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote motor: " + e.Msg
+}
+
+// rpc 服务对象：只负责查找发动机并驱动
+type MotorService struct {
+	server *MotorServer
+}
+
+func (m *MotorService) Drive(args *DriveArgs, reply *DriveReply) (err error) {
+	motor, ok := m.server.Motor(args.Name)
+	if !ok {
+		return fmt.Errorf("unknown motor %q", args.Name)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("motor %q panicked: %v", args.Name, r)
+		}
+	}()
+	motor.Drive()
+	return nil
+}
+
+// 发动机服务
+type MotorServer struct {
+	mu       sync.RWMutex
+	motors   map[string]Motor
+	rpc      *rpc.Server
+	listener net.Listener
+	conns    map[net.Conn]struct{} // 正在服务的连接，关闭服务时一并关闭
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// 注册发动机，同名发动机会被替换
+func (s *MotorServer) Register(name string, motor Motor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.motors[name] = motor
+}
+
+func (s *MotorServer) Motor(name string) (Motor, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.motors[name]
+	return m, ok
+}
+
+// 在指定地址上监听，network 只允许 unix 或回环地址上的 tcp；每个服务只能监听一次，关闭后不能再监听
+func (s *MotorServer) Listen(network, address string) error {
+	if err := checkLocalAddress(network, address); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrServerClosed
+	}
+	if s.listener != nil {
+		return fmt.Errorf("server is already listening on %s", s.listener.Addr())
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	s.listener = l
+	s.wg.Add(1)
+	go s.serve(l)
+	return nil
+}
+
+func (s *MotorServer) serve(l net.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		// Close 已经关闭了所有连接，之后接受的连接不再服务
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.rpc.ServeConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// 实际监听的地址，用于端口为 0 的情况
+func (s *MotorServer) Addr() net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// 停止监听并关闭所有连接，等待正在处理的调用返回
+func (s *MotorServer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func CreateMotorServer() *MotorServer {
+	s := &MotorServer{motors: map[string]Motor{}, conns: map[net.Conn]struct{}{}, rpc: rpc.NewServer()}
+	if err := s.rpc.RegisterName("Motor", &MotorService{server: s}); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func checkLocalAddress(network, address string) error {
+	switch network {
+	case "unix":
+		return nil
+	case "tcp", "tcp4", "tcp6":
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("address %q is not a loopback address", address)
+	}
+	return fmt.Errorf("unsupported network %q", network)
+}
+
+// 远程适配器：实现 Motor，把请求转发给发动机服务
+type RemoteAdapter struct {
+	network string
+	address string
+	name    string
+	timeout time.Duration
+	retries int
+
+	mu     sync.Mutex
+	client *rpc.Client
+	err    error // 最近一次 Drive 的错误
+}
+
+func (r *RemoteAdapter) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+func (r *RemoteAdapter) SetRetries(retries int) {
+	r.retries = retries
+}
+
+// Motor 接口没有返回值，错误会被记录下来，通过 Err() 获取
+func (r *RemoteAdapter) Drive() {
+	err := r.DriveErr()
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 最近一次 Drive 的错误
+func (r *RemoteAdapter) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// 带错误返回的驱动方法：连接失败或连接已断开时会重连，远程错误以 *RemoteError 返回；
+// 调用超时返回 ErrCallTimeout，不会重试，以免发动机被驱动两次
+func (r *RemoteAdapter) DriveErr() error {
+	var err error
+	for i := 0; i <= r.retries; i++ {
+		var client *rpc.Client
+		client, err = r.connect()
+		if err != nil {
+			continue
+		}
+		err = r.call(client)
+		if !disconnected(err) {
+			return err
+		}
+		// 请求没有送达服务端：丢弃连接，下一轮重连
+		r.reset(client)
+	}
+	return err
+}
+
+// 连接已经断开，请求没有被服务端执行
+func disconnected(err error) bool {
+	if err == nil {
+		return false
+	}
+	// 等待回复时连接断开（io.ErrUnexpectedEOF）不算：服务端可能已经执行了这次调用
+	if errors.Is(err, rpc.ErrShutdown) || err == io.EOF {
+		return true
+	}
+	// 发送请求时的写错误
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "write"
+}
+
+func (r *RemoteAdapter) call(client *rpc.Client) error {
+	call := client.Go("Motor.Drive", &DriveArgs{Name: r.name}, &DriveReply{}, make(chan *rpc.Call, 1))
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+	select {
+	case <-call.Done:
+		var serverErr rpc.ServerError
+		if errors.As(call.Error, &serverErr) {
+			return &RemoteError{Msg: string(serverErr)}
+		}
+		return call.Error
+	case <-timer.C:
+		return fmt.Errorf("remote motor %q: %w after %v", r.name, ErrCallTimeout, r.timeout)
+	}
+}
+
+func (r *RemoteAdapter) connect() (*rpc.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		return r.client, nil
+	}
+	conn, err := net.DialTimeout(r.network, r.address, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	r.client = rpc.NewClient(conn)
+	return r.client, nil
+}
+
+func (r *RemoteAdapter) reset(client *rpc.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == client {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+func (r *RemoteAdapter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
+}
+
+func CreateRemoteAdapter(network, address, name string) *RemoteAdapter {
+	return &RemoteAdapter{
+		network: network,
+		address: address,
+		name:    name,
+		timeout: defaultRemoteTimeout,
+		retries: defaultRemoteRetries,
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试用发动机：记录被驱动的次数
+type testMotor struct {
+	drives atomic.Int32
+	delay  time.Duration
+	panics bool
+}
+
+func (m *testMotor) Drive() {
+	time.Sleep(m.delay)
+	m.drives.Add(1)
+	if m.panics {
+		panic("motor burnt out")
+	}
+}
+
+func startServer(t *testing.T, network, address string, motors map[string]Motor) *MotorServer {
+	t.Helper()
+	s := CreateMotorServer()
+	for name, m := range motors {
+		s.Register(name, m)
+	}
+	if err := s.Listen(network, address); err != nil {
+		t.Fatalf("listen %s %s: %v", network, address, err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testNetworks(t *testing.T) map[string]string {
+	return map[string]string{
+		"unix": filepath.Join(t.TempDir(), "motor.sock"),
+		"tcp":  "127.0.0.1:0",
+	}
+}
+
+func TestRemoteDrive(t *testing.T) {
+	for network, address := range testNetworks(t) {
+		t.Run(network, func(t *testing.T) {
+			motor := &testMotor{}
+			s := startServer(t, network, address, map[string]Motor{"electric": motor})
+			r := CreateRemoteAdapter(network, s.Addr().String(), "electric")
+			defer r.Close()
+			if err := r.DriveErr(); err != nil {
+				t.Fatalf("DriveErr() = %v", err)
+			}
+			if n := motor.drives.Load(); n != 1 {
+				t.Fatalf("motor driven %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestRemoteErrors(t *testing.T) {
+	for network, address := range testNetworks(t) {
+		t.Run(network, func(t *testing.T) {
+			s := startServer(t, network, address, map[string]Motor{"broken": &testMotor{panics: true}})
+			for _, name := range []string{"missing", "broken"} {
+				r := CreateRemoteAdapter(network, s.Addr().String(), name)
+				err := r.DriveErr()
+				r.Close()
+				var remote *RemoteError
+				if !errors.As(err, &remote) {
+					t.Errorf("%s: DriveErr() = %v, want *RemoteError", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteTimeoutDoesNotRetry(t *testing.T) {
+	motor := &testMotor{delay: 200 * time.Millisecond}
+	s := startServer(t, "tcp", "127.0.0.1:0", map[string]Motor{"slow": motor})
+	r := CreateRemoteAdapter("tcp", s.Addr().String(), "slow")
+	defer r.Close()
+	r.SetTimeout(50 * time.Millisecond)
+	r.SetRetries(3)
+	if err := r.DriveErr(); !errors.Is(err, ErrCallTimeout) {
+		t.Fatalf("DriveErr() = %v, want ErrCallTimeout", err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if n := motor.drives.Load(); n != 1 {
+		t.Fatalf("motor driven %d times after a timeout, want 1", n)
+	}
+}
+
+func TestRemoteReconnect(t *testing.T) {
+	first := &testMotor{}
+	s := startServer(t, "tcp", "127.0.0.1:0", map[string]Motor{"electric": first})
+	addr := s.Addr().String()
+	r := CreateRemoteAdapter("tcp", addr, "electric")
+	defer r.Close()
+	if err := r.DriveErr(); err != nil {
+		t.Fatalf("DriveErr() = %v", err)
+	}
+
+	// 重启服务：旧的连接被关闭，客户端需要重连
+	s.Close()
+	if err := r.DriveErr(); err == nil {
+		t.Fatal("DriveErr() succeeded while the server was down")
+	}
+	second := &testMotor{}
+	restarted := CreateMotorServer()
+	restarted.Register("electric", second)
+	if err := restarted.Listen("tcp", addr); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	defer restarted.Close()
+	if err := r.DriveErr(); err != nil {
+		t.Fatalf("DriveErr() after restart = %v", err)
+	}
+	if first.drives.Load() != 1 || second.drives.Load() != 1 {
+		t.Fatalf("drives = %d, %d; want 1, 1", first.drives.Load(), second.drives.Load())
+	}
+}
+
+func TestListenRejectsNonLoopback(t *testing.T) {
+	s := CreateMotorServer()
+	if err := s.Listen("tcp", net.JoinHostPort("0.0.0.0", "0")); err == nil {
+		s.Close()
+		t.Fatal("Listen on 0.0.0.0 succeeded")
+	}
+}
+
+func TestListenTwice(t *testing.T) {
+	s := startServer(t, "tcp", "127.0.0.1:0", nil)
+	addr := s.Addr()
+	if err := s.Listen("tcp", "127.0.0.1:0"); err == nil {
+		t.Fatal("second Listen succeeded")
+	}
+	if s.Addr() != addr {
+		t.Fatalf("Addr() = %v after second Listen, want %v", s.Addr(), addr)
+	}
+	s.Close()
+	if err := s.Listen("tcp", "127.0.0.1:0"); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("Listen after Close = %v, want ErrServerClosed", err)
+	}
+}
+
+// 测试用监听器：关闭之后才返回一个连接，模拟 Accept 与 Close 同时发生
+type lateListener struct {
+	closed   chan struct{}
+	accepted bool
+	client   net.Conn // 连接的客户端一侧，不主动断开
+}
+
+func (l *lateListener) Accept() (net.Conn, error) {
+	<-l.closed
+	if l.accepted {
+		return nil, net.ErrClosed
+	}
+	l.accepted = true
+	// 让 Close 先处理完已有的连接
+	time.Sleep(20 * time.Millisecond)
+	server, client := net.Pipe()
+	l.client = client
+	return server, nil
+}
+
+func (l *lateListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func (l *lateListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "late", Net: "unix"}
+}
+
+// 关闭之后才接受的连接也要关闭，否则 Close 会一直等这个连接
+func TestCloseRacesAccept(t *testing.T) {
+	s := CreateMotorServer()
+	l := &lateListener{closed: make(chan struct{})}
+	s.listener = l
+	s.wg.Add(1)
+	go s.serve(l)
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		l.client.Close()
+		t.Fatal("Close did not return")
+	}
+}
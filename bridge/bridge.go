@@ -23,13 +23,47 @@
 
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
 
 func main() {
 	color := &Yellow{}
 	bag := CreateBag(color)
 	handBag := CreateHandBag(bag)
 	fmt.Println(handBag.GetName())
+
+	// 多维度桥接：颜色、皮质、大小
+	wallet := CreateWallet(CreateBagOf(&Red{}, &Leather{}, &Small{}))
+	fmt.Println(wallet.GetName(), wallet.GetPrice())
+
+	// 渲染器：任意一种包都可以在任意一种渲染器上输出
+	Render(wallet, CreateASCIIRenderer(false), os.Stdout)
+
+	// 本地化：按调用或按 context 选择语言
+	name, _ := DefaultLocalizer.Name(LocaleZhCN, wallet)
+	fmt.Println(name)
+	ctx := WithLocale(context.Background(), LocaleEnUS)
+	name, _ = DefaultLocalizer.NameContext(ctx, wallet)
+	fmt.Println(name)
+
+	// 商品目录：枚举所有包种类与颜色的组合
+	catalogue := CreateCatalogue()
+	catalogue.RegisterBag("HandBag", func(bag *Bag) Product { return CreateHandBag(bag) })
+	catalogue.RegisterBag("Wallet", func(bag *Bag) Product { return CreateWallet(bag) })
+	catalogue.RegisterColor(&Yellow{})
+	catalogue.RegisterColor(&Red{})
+	catalogue.RegisterMaterial(&Leather{})
+	catalogue.RegisterMaterial(&Canvas{})
+	catalogue.ExportJSON(os.Stdout, ColorIs("Red"), MaterialIs("Leather"))
+
+	// 颜色模型：由 HSL 构造颜色，在调色板中查找最接近的命名颜色
+	orange := CreateHSLColor(30, 1, 0.5)
+	fmt.Println(orange.GetColor(), DefaultPalette.Nearest(orange.RGB()).GetColor())
+	fmt.Printf("%.2f\n", ContrastRatio(orange.RGB(), RGB{0xFF, 0xFF, 0xFF}))
 }
 
 /*
@@ -50,7 +84,15 @@ type Yellow struct {
 }
 
 func (y *Yellow) GetColor() string {
-	return "Yellow"
+	return yellow.GetColor()
+}
+
+func (y *Yellow) RGB() RGB {
+	return yellow.RGB()
+}
+
+func (y *Yellow) Surcharge() int {
+	return 0
 }
 
 // 具体实现化角色：红色
@@ -58,16 +100,72 @@ type Red struct {
 }
 
 func (r *Red) GetColor() string {
-	return "Red"
+	return red.GetColor()
+}
+
+func (r *Red) RGB() RGB {
+	return red.RGB()
+}
+
+// 红色加价 10 元
+func (r *Red) Surcharge() int {
+	return 1000
 }
 
 // 抽象化角色：包
 type Bag struct {
-	Color Color
+	Color    Color
+	Material Material // 可以为空
+	Size     Size     // 可以为空
+}
+
+// 各维度组合后的描述，如 Large Red Leather
+func (b *Bag) Describe() string {
+	var parts []string
+	if b.Size != nil {
+		parts = append(parts, b.Size.GetSize())
+	}
+	parts = append(parts, b.Color.GetColor())
+	if b.Material != nil {
+		parts = append(parts, b.Material.GetMaterial())
+	}
+	return strings.Join(parts, " ")
+}
+
+// 各维度的加价之和
+func (b *Bag) Surcharge() int {
+	total := 0
+	for _, v := range []interface{}{b.Color, b.Material, b.Size} {
+		if s, ok := v.(Surcharger); ok {
+			total += s.Surcharge()
+		}
+	}
+	return total
+}
+
+// 组合各维度，生成指定种类的包的名称
+func (b *Bag) Name(kind string) string {
+	return b.Describe() + " " + kind
+}
+
+// 组合各维度，生成指定基础价格的包的价格
+func (b *Bag) Price(basePrice int) int {
+	return basePrice + b.Surcharge()
 }
 
 func CreateBag(c Color) *Bag {
-	return &Bag{c}
+	return &Bag{Color: c}
+}
+
+func CreateBagOf(c Color, m Material, s Size) *Bag {
+	return &Bag{Color: c, Material: m, Size: s}
+}
+
+// 扩展抽象化角色的公共接口：商品
+type Product interface {
+	GetName() string
+	GetBasePrice() int // 基础价格，单位：分
+	GetPrice() int     // 基础价格加上各维度的加价，单位：分
 }
 
 // 扩展抽象化角色：挎包
@@ -76,20 +174,52 @@ type HandBag struct {
 }
 
 func (h *HandBag) GetName() string {
-	return h.bag.Color.GetColor() + " HandBag"
+	return h.bag.Name(h.GetKind())
+}
+
+func (h *HandBag) GetKind() string {
+	return "HandBag"
+}
+
+func (h *HandBag) GetBag() *Bag {
+	return h.bag
+}
+
+func (h *HandBag) GetBasePrice() int {
+	return 29900
+}
+
+func (h *HandBag) GetPrice() int {
+	return h.bag.Price(h.GetBasePrice())
 }
 
 func CreateHandBag(bag *Bag) *HandBag {
 	return &HandBag{bag}
 }
 
-// 扩展抽象化角色：挎包
+// 扩展抽象化角色：钱包
 type Wallet struct {
 	bag *Bag
 }
 
 func (h *Wallet) GetName() string {
-	return h.bag.Color.GetColor() + " Wallet"
+	return h.bag.Name(h.GetKind())
+}
+
+func (h *Wallet) GetKind() string {
+	return "Wallet"
+}
+
+func (h *Wallet) GetBag() *Bag {
+	return h.bag
+}
+
+func (h *Wallet) GetBasePrice() int {
+	return 9900
+}
+
+func (h *Wallet) GetPrice() int {
+	return h.bag.Price(h.GetBasePrice())
 }
 
 func CreateWallet(bag *Bag) *Wallet {
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+	商品目录：桥接模式让包的种类和颜色、皮质、大小可以独立扩展，目录把已登记的包种类和各维度的实现逐一组合，
+	为每个组合生成稳定的 SKU、价格（基础价格 + 各维度加价）和展示名称，并支持筛选和导出 JSON。
+	没有登记皮质或大小时，该维度不参与组合。
+*/
+
+// 包种类：由 Bag 构造扩展抽象化角色
+type BagKind struct {
+	Code   string // 种类编码，用于生成 SKU
+	Create func(bag *Bag) Product
+}
+
+// 目录条目
+type CatalogueItem struct {
+	SKU      string  `json:"sku"`
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`
+	Color    string  `json:"color"`
+	Material string  `json:"material,omitempty"`
+	Size     string  `json:"size,omitempty"`
+	Price    int     `json:"price"` // 单位：分
+	Product  Product `json:"-"`
+}
+
+// 筛选条件
+type CatalogueFilter func(item *CatalogueItem) bool
+
+// 商品目录
+type Catalogue struct {
+	kinds     []*BagKind
+	colors    []Color
+	materials []Material
+	sizes     []Size
+}
+
+// 登记包种类，编码重复时返回错误
+func (c *Catalogue) RegisterBag(code string, create func(bag *Bag) Product) error {
+	code = skuPart(code)
+	for _, k := range c.kinds {
+		if k.Code == code {
+			return fmt.Errorf("bag kind %q already registered", code)
+		}
+	}
+	c.kinds = append(c.kinds, &BagKind{Code: code, Create: create})
+	return nil
+}
+
+// 登记颜色，颜色名称重复时返回错误；颜色的加价由颜色自己实现 Surcharger
+func (c *Catalogue) RegisterColor(color Color) error {
+	for _, v := range c.colors {
+		if skuPart(v.GetColor()) == skuPart(color.GetColor()) {
+			return fmt.Errorf("color %q already registered", color.GetColor())
+		}
+	}
+	c.colors = append(c.colors, color)
+	return nil
+}
+
+// 登记皮质，名称重复时返回错误
+func (c *Catalogue) RegisterMaterial(m Material) error {
+	for _, v := range c.materials {
+		if skuPart(v.GetMaterial()) == skuPart(m.GetMaterial()) {
+			return fmt.Errorf("material %q already registered", m.GetMaterial())
+		}
+	}
+	c.materials = append(c.materials, m)
+	return nil
+}
+
+// 登记大小，名称重复时返回错误
+func (c *Catalogue) RegisterSize(s Size) error {
+	for _, v := range c.sizes {
+		if skuPart(v.GetSize()) == skuPart(s.GetSize()) {
+			return fmt.Errorf("size %q already registered", s.GetSize())
+		}
+	}
+	c.sizes = append(c.sizes, s)
+	return nil
+}
+
+// 枚举所有组合，结果按 SKU 排序
+func (c *Catalogue) Items() []*CatalogueItem {
+	materials := c.materials
+	if len(materials) == 0 {
+		materials = []Material{nil}
+	}
+	sizes := c.sizes
+	if len(sizes) == 0 {
+		sizes = []Size{nil}
+	}
+	var items []*CatalogueItem
+	for _, k := range c.kinds {
+		for _, color := range c.colors {
+			for _, m := range materials {
+				for _, s := range sizes {
+					items = append(items, c.item(k, color, m, s))
+				}
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].SKU < items[j].SKU
+	})
+	return items
+}
+
+func (c *Catalogue) item(k *BagKind, color Color, m Material, s Size) *CatalogueItem {
+	product := k.Create(CreateBagOf(color, m, s))
+	item := &CatalogueItem{
+		SKU:     k.Code + "-" + skuPart(color.GetColor()),
+		Name:    product.GetName(),
+		Kind:    k.Code,
+		Color:   color.GetColor(),
+		Price:   product.GetPrice(),
+		Product: product,
+	}
+	if m != nil {
+		item.Material = m.GetMaterial()
+		item.SKU += "-" + skuPart(item.Material)
+	}
+	if s != nil {
+		item.Size = s.GetSize()
+		item.SKU += "-" + skuPart(item.Size)
+	}
+	return item
+}
+
+// 按所有条件筛选
+func (c *Catalogue) Filter(filters ...CatalogueFilter) []*CatalogueItem {
+	var items []*CatalogueItem
+next:
+	for _, item := range c.Items() {
+		for _, f := range filters {
+			if !f(item) {
+				continue next
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// 导出 JSON
+func (c *Catalogue) ExportJSON(w io.Writer, filters ...CatalogueFilter) error {
+	items := c.Filter(filters...)
+	if items == nil {
+		items = []*CatalogueItem{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+func CreateCatalogue() *Catalogue {
+	return &Catalogue{}
+}
+
+// 按包种类筛选
+func KindIs(code string) CatalogueFilter {
+	code = skuPart(code)
+	return func(item *CatalogueItem) bool {
+		return item.Kind == code
+	}
+}
+
+// 按颜色筛选
+func ColorIs(color string) CatalogueFilter {
+	return func(item *CatalogueItem) bool {
+		return strings.EqualFold(item.Color, color)
+	}
+}
+
+// 按皮质筛选
+func MaterialIs(material string) CatalogueFilter {
+	return func(item *CatalogueItem) bool {
+		return strings.EqualFold(item.Material, material)
+	}
+}
+
+// 按大小筛选
+func SizeIs(size string) CatalogueFilter {
+	return func(item *CatalogueItem) bool {
+		return strings.EqualFold(item.Size, size)
+	}
+}
+
+// 按价格区间筛选，包含两端
+func PriceBetween(min, max int) CatalogueFilter {
+	return func(item *CatalogueItem) bool {
+		return item.Price >= min && item.Price <= max
+	}
+}
+
+// SKU 片段：大写字母和数字，其他 ASCII 字符替换为下划线；
+// 含有非 ASCII 字符（如中文名称）时无法直接转写，改用名称的 FNV-1a 哈希，如 "黄色" -> "H" + 8 位十六进制
+func skuPart(s string) string {
+	s = strings.TrimSpace(s)
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			h := fnv.New32a()
+			h.Write([]byte(s))
+			return fmt.Sprintf("H%08X", h.Sum32())
+		}
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*
+	颜色模型：具体实现化角色不再只返回固定的字符串，而是带有 RGB 值，
+	可以由十六进制、RGB 或 HSL 构造，并提供颜色转换、对比度检查以及最接近的命名颜色查找。
+	命名颜色保存在调色板中，调色板可以从文件加载；默认调色板内嵌自 palette.txt，原有的黄色和红色就是其中的条目。
+*/
+
+// RGB 值
+type RGB struct {
+	R, G, B uint8
+}
+
+// 解析十六进制颜色，支持 #RRGGBB、RRGGBB 和 #RGB
+func ParseHex(s string) (RGB, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return RGB{}, fmt.Errorf("invalid hex color %q", s)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid hex color %q", s)
+	}
+	return RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// 由 HSL 构造 RGB：h 取值 [0, 360)，s、l 取值 [0, 1]
+func HSLToRGB(h, s, l float64) RGB {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	s = clamp01(s)
+	l = clamp01(l)
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return RGB{to8(r + m), to8(g + m), to8(b + m)}
+}
+
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// 转换为 HSL：h 取值 [0, 360)，s、l 取值 [0, 1]
+func (c RGB) HSL() (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	d := max - min
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch max {
+	case r:
+		h = 60 * math.Mod((g-b)/d, 6)
+	case g:
+		h = 60 * ((b-r)/d + 2)
+	default:
+		h = 60 * ((r-g)/d + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// 相对亮度（WCAG 2.x）
+func (c RGB) Luminance() float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// 两种颜色的对比度，取值 [1, 21]
+func ContrastRatio(a, b RGB) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// 前景色在背景色上是否清晰可读（WCAG AA 普通文本，对比度不低于 4.5）
+func Readable(fg, bg RGB) bool {
+	return ContrastRatio(fg, bg) >= 4.5
+}
+
+func distance(a, b RGB) float64 {
+	dr := float64(a.R) - float64(b.R)
+	dg := float64(a.G) - float64(b.G)
+	db := float64(a.B) - float64(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func to8(v float64) uint8 {
+	return uint8(math.Round(clamp01(v) * 255))
+}
+
+// 具体实现化角色：RGB 颜色
+type RGBColor struct {
+	name string
+	rgb  RGB
+}
+
+// 命名颜色返回名称，否则返回十六进制值
+func (c *RGBColor) GetColor() string {
+	if c.name != "" {
+		return c.name
+	}
+	return c.rgb.Hex()
+}
+
+func (c *RGBColor) RGB() RGB {
+	return c.rgb
+}
+
+func CreateRGBColor(r, g, b uint8) *RGBColor {
+	return &RGBColor{rgb: RGB{r, g, b}}
+}
+
+func CreateHexColor(hex string) (*RGBColor, error) {
+	rgb, err := ParseHex(hex)
+	if err != nil {
+		return nil, err
+	}
+	return &RGBColor{rgb: rgb}, nil
+}
+
+func CreateHSLColor(h, s, l float64) *RGBColor {
+	return &RGBColor{rgb: HSLToRGB(h, s, l)}
+}
+
+func CreateNamedColor(name string, rgb RGB) *RGBColor {
+	return &RGBColor{name: name, rgb: rgb}
+}
+
+// 带有 RGB 值的颜色
+type RGBer interface {
+	RGB() RGB
+}
+
+// 取颜色的 RGB 值：优先使用颜色自身的 RGB 值，否则按名称在默认调色板中查找
+func RGBOf(c Color) (RGB, bool) {
+	if v, ok := c.(RGBer); ok {
+		return v.RGB(), true
+	}
+	if v, ok := DefaultPalette.Get(c.GetColor()); ok {
+		return v.RGB(), true
+	}
+	return RGB{}, false
+}
+
+// 调色板：命名颜色的集合，名称不区分大小写
+type Palette struct {
+	colors []*RGBColor
+	index  map[string]*RGBColor
+}
+
+func (p *Palette) Add(c *RGBColor) error {
+	if c.name == "" {
+		return fmt.Errorf("palette color %s has no name", c.rgb.Hex())
+	}
+	key := strings.ToLower(c.name)
+	if _, ok := p.index[key]; ok {
+		return fmt.Errorf("palette color %q already exists", c.name)
+	}
+	p.colors = append(p.colors, c)
+	p.index[key] = c
+	return nil
+}
+
+func (p *Palette) Get(name string) (*RGBColor, bool) {
+	c, ok := p.index[strings.ToLower(strings.TrimSpace(name))]
+	return c, ok
+}
+
+func (p *Palette) Colors() []*RGBColor {
+	return append([]*RGBColor(nil), p.colors...)
+}
+
+// 最接近的命名颜色，调色板为空时返回 nil
+func (p *Palette) Nearest(rgb RGB) *RGBColor {
+	var best *RGBColor
+	bestDist := math.Inf(1)
+	for _, c := range p.colors {
+		if d := distance(c.rgb, rgb); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func CreatePalette(colors ...*RGBColor) (*Palette, error) {
+	p := &Palette{index: map[string]*RGBColor{}}
+	for _, c := range colors {
+		if err := p.Add(c); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// 读取调色板，每行一个颜色：名称 #RRGGBB；空行和 // 开头的行被忽略
+func ReadPalette(r io.Reader) (*Palette, error) {
+	p, _ := CreatePalette()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		i := strings.LastIndexAny(text, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("palette line %d: want \"name #RRGGBB\"", line)
+		}
+		rgb, err := ParseHex(text[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("palette line %d: %v", line, err)
+		}
+		if err := p.Add(CreateNamedColor(strings.TrimSpace(text[:i]), rgb)); err != nil {
+			return nil, fmt.Errorf("palette line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func LoadPalette(path string) (*Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPalette(f)
+}
+
+//go:embed palette.txt
+var paletteFile string
+
+// 默认调色板：由内嵌的 palette.txt 构建，原有的黄色和红色也在其中
+var (
+	DefaultPalette = mustReadPalette(paletteFile)
+
+	yellow = mustGet(DefaultPalette, "Yellow")
+	red    = mustGet(DefaultPalette, "Red")
+)
+
+func mustReadPalette(text string) *Palette {
+	p, err := ReadPalette(strings.NewReader(text))
+	if err != nil {
+		panic("default palette: " + err.Error())
+	}
+	return p
+}
+
+func mustGet(p *Palette, name string) *RGBColor {
+	c, ok := p.Get(name)
+	if !ok {
+		panic(fmt.Sprintf("default palette has no color %q", name))
+	}
+	return c
+}
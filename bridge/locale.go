@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+)
+
+/*
+	本地化是名称的另一个变化维度：消息目录（MessageCatalogue）保存某种语言下颜色、皮质、大小和包种类的名称，
+	以及决定词序的模板；本地化器（Localizer）按语言选择消息目录，缺少的翻译回退到默认语言。
+	语言可以在每次调用时指定，也可以通过 context 传递。
+*/
+
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+)
+
+// 可以本地化名称的扩展抽象化角色
+type Localizable interface {
+	GetKind() string // 包种类，如 HandBag
+	GetBag() *Bag
+}
+
+// 模板数据：各维度已翻译的名称，没有的维度为空字符串
+type NameParts struct {
+	Kind     string
+	Color    string
+	Material string
+	Size     string
+}
+
+// 消息目录：一种语言的翻译和词序模板
+type MessageCatalogue struct {
+	Locale   string
+	Messages map[string]string
+	Template *template.Template
+}
+
+func CreateMessageCatalogue(locale string, messages map[string]string, tmpl string) (*MessageCatalogue, error) {
+	t, err := template.New(locale).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("locale %s: %v", locale, err)
+	}
+	return &MessageCatalogue{Locale: locale, Messages: messages, Template: t}, nil
+}
+
+// 本地化器
+type Localizer struct {
+	defaultLocale string
+	catalogues    map[string]*MessageCatalogue
+}
+
+func (l *Localizer) Add(c *MessageCatalogue) {
+	l.catalogues[c.Locale] = c
+}
+
+// 查找顺序：指定语言、默认语言，都没有时返回 key 本身
+func (l *Localizer) Translate(locale, key string) string {
+	if msg, ok := l.lookup(locale, key); ok {
+		return msg
+	}
+	return key
+}
+
+func (l *Localizer) lookup(locale, key string) (string, bool) {
+	for _, loc := range []string{locale, l.defaultLocale} {
+		if c, ok := l.catalogues[loc]; ok {
+			if msg, ok := c.Messages[key]; ok {
+				return msg, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 翻译某个维度的名称，如 color.Yellow，都没有时返回名称本身
+func (l *Localizer) part(locale, axis, name string) string {
+	if msg, ok := l.lookup(locale, axis+"."+name); ok {
+		return msg
+	}
+	return name
+}
+
+func (l *Localizer) template(locale string) (*template.Template, error) {
+	for _, loc := range []string{locale, l.defaultLocale} {
+		if c, ok := l.catalogues[loc]; ok && c.Template != nil {
+			return c.Template, nil
+		}
+	}
+	return nil, fmt.Errorf("no name template for locale %s", locale)
+}
+
+// 指定语言的名称
+func (l *Localizer) Name(locale string, p Localizable) (string, error) {
+	bag := p.GetBag()
+	parts := NameParts{
+		Kind:  l.part(locale, "kind", p.GetKind()),
+		Color: l.part(locale, "color", bag.Color.GetColor()),
+	}
+	if bag.Material != nil {
+		parts.Material = l.part(locale, "material", bag.Material.GetMaterial())
+	}
+	if bag.Size != nil {
+		parts.Size = l.part(locale, "size", bag.Size.GetSize())
+	}
+	t, err := l.template(locale)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, parts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// context 中指定语言的名称，没有指定时使用默认语言
+func (l *Localizer) NameContext(ctx context.Context, p Localizable) (string, error) {
+	locale, ok := LocaleFrom(ctx)
+	if !ok {
+		locale = l.defaultLocale
+	}
+	return l.Name(locale, p)
+}
+
+func CreateLocalizer(defaultLocale string) *Localizer {
+	return &Localizer{defaultLocale: defaultLocale, catalogues: map[string]*MessageCatalogue{}}
+}
+
+type localeKey struct{}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+func LocaleFrom(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// 默认本地化器：内置 en-US 和 zh-CN 消息目录，未翻译的颜色名（如十六进制值）原样输出
+var DefaultLocalizer = func() *Localizer {
+	l := CreateLocalizer(LocaleEnUS)
+	en, err := CreateMessageCatalogue(LocaleEnUS, map[string]string{
+		"kind.HandBag":     "HandBag",
+		"kind.Wallet":      "Wallet",
+		"color.Yellow":     "Yellow",
+		"color.Red":        "Red",
+		"material.Leather": "Leather",
+		"material.Canvas":  "Canvas",
+		"size.Small":       "Small",
+		"size.Medium":      "Medium",
+		"size.Large":       "Large",
+	}, "{{with .Size}}{{.}} {{end}}{{.Color}} {{with .Material}}{{.}} {{end}}{{.Kind}}")
+	if err != nil {
+		panic(err)
+	}
+	zh, err := CreateMessageCatalogue(LocaleZhCN, map[string]string{
+		"kind.HandBag":     "挎包",
+		"kind.Wallet":      "钱包",
+		"color.Yellow":     "黄色",
+		"color.Red":        "红色",
+		"material.Leather": "皮革",
+		"material.Canvas":  "帆布",
+		"size.Small":       "小号",
+		"size.Medium":      "中号",
+		"size.Large":       "大号",
+	}, "{{.Size}}{{.Color}}{{.Material}}{{.Kind}}")
+	if err != nil {
+		panic(err)
+	}
+	l.Add(en)
+	l.Add(zh)
+	return l
+}()
@@ -0,0 +1,83 @@
+package main
+
+/*
+	皮包除了颜色之外，还可以按皮质和大小变化。皮质类（Material）和大小类（Size）是与颜色并列的实现化角色，
+	包类同时聚合这三个维度，增加任何一个维度上的具体实现化角色都不需要修改挎包和钱包。
+*/
+
+// 加价：实现化角色可以选择实现，单位：分
+type Surcharger interface {
+	Surcharge() int
+}
+
+// 实现化角色：皮质
+type Material interface {
+	GetMaterial() string
+	Surcharge() int
+}
+
+// 具体实现化角色：皮革
+type Leather struct {
+}
+
+func (l *Leather) GetMaterial() string {
+	return "Leather"
+}
+
+func (l *Leather) Surcharge() int {
+	return 20000
+}
+
+// 具体实现化角色：帆布
+type Canvas struct {
+}
+
+func (c *Canvas) GetMaterial() string {
+	return "Canvas"
+}
+
+func (c *Canvas) Surcharge() int {
+	return 0
+}
+
+// 实现化角色：大小
+type Size interface {
+	GetSize() string
+	Surcharge() int
+}
+
+// 具体实现化角色：小号
+type Small struct {
+}
+
+func (s *Small) GetSize() string {
+	return "Small"
+}
+
+func (s *Small) Surcharge() int {
+	return 0
+}
+
+// 具体实现化角色：中号
+type Medium struct {
+}
+
+func (m *Medium) GetSize() string {
+	return "Medium"
+}
+
+func (m *Medium) Surcharge() int {
+	return 3000
+}
+
+// 具体实现化角色：大号
+type Large struct {
+}
+
+func (l *Large) GetSize() string {
+	return "Large"
+}
+
+func (l *Large) Surcharge() int {
+	return 6000
+}
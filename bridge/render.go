@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+/*
+	渲染是包的另一个变化维度：渲染器类（Renderer）是实现化角色，提供画布、矩形和文字等绘图原语，
+	它有三个具体实现化角色：字符画（ASCII）、SVG 和 PNG；
+	挎包和钱包通过 Draw() 方法用这些原语画出自己，任意一种包都可以在任意一种渲染器上输出。
+	坐标使用抽象的格子单位，由各个渲染器决定一个格子对应多少字符或像素。
+*/
+
+// 实现化角色：渲染器
+type Renderer interface {
+	Begin(width, height int)                   // 开始新的画布
+	FillRect(x, y, width, height int, c RGB)   // 填充矩形
+	StrokeRect(x, y, width, height int, c RGB) // 描边矩形
+	Text(x, y int, text string, c RGB)         // 文字，(x, y) 为文字左端所在的格子
+	Flush(w io.Writer) error                   // 输出画布
+}
+
+// 可以被渲染的扩展抽象化角色
+type Drawable interface {
+	Draw(r Renderer)
+}
+
+// 在渲染器上画出 d 并输出
+func Render(d Drawable, r Renderer, w io.Writer) error {
+	d.Draw(r)
+	return r.Flush(w)
+}
+
+var (
+	outlineRGB = RGB{0x33, 0x33, 0x33}
+	unknownRGB = RGB{0x99, 0x99, 0x99}
+)
+
+// 包的颜色，没有 RGB 值的颜色画成灰色
+func fillOf(b *Bag) RGB {
+	if rgb, ok := RGBOf(b.Color); ok {
+		return rgb
+	}
+	return unknownRGB
+}
+
+func darken(c RGB, f float64) RGB {
+	return RGB{uint8(float64(c.R) * f), uint8(float64(c.G) * f), uint8(float64(c.B) * f)}
+}
+
+// 具体实现化角色：字符画渲染器
+type ASCIIRenderer struct {
+	ansi   bool // 是否输出 ANSI 真彩色
+	width  int
+	height int
+	cells  [][]rune
+	colors [][]*RGB
+}
+
+func (a *ASCIIRenderer) Begin(width, height int) {
+	a.width, a.height = width, height
+	a.cells = make([][]rune, height)
+	a.colors = make([][]*RGB, height)
+	for y := range a.cells {
+		a.cells[y] = []rune(strings.Repeat(" ", width))
+		a.colors[y] = make([]*RGB, width)
+	}
+}
+
+func (a *ASCIIRenderer) set(x, y int, r rune, c RGB) {
+	if x < 0 || y < 0 || x >= a.width || y >= a.height {
+		return
+	}
+	a.cells[y][x] = r
+	a.colors[y][x] = &c
+}
+
+func (a *ASCIIRenderer) FillRect(x, y, width, height int, c RGB) {
+	// 按亮度选择字符，越暗的颜色字符越密
+	ramp := []rune("@#%Oo*:;.")
+	r := ramp[int(c.Luminance()*float64(len(ramp)-1)+0.5)]
+	for j := y; j < y+height; j++ {
+		for i := x; i < x+width; i++ {
+			a.set(i, j, r, c)
+		}
+	}
+}
+
+func (a *ASCIIRenderer) StrokeRect(x, y, width, height int, c RGB) {
+	right, bottom := x+width-1, y+height-1
+	for i := x + 1; i < right; i++ {
+		a.set(i, y, '-', c)
+		a.set(i, bottom, '-', c)
+	}
+	for j := y + 1; j < bottom; j++ {
+		a.set(x, j, '|', c)
+		a.set(right, j, '|', c)
+	}
+	for _, p := range [][2]int{{x, y}, {right, y}, {x, bottom}, {right, bottom}} {
+		a.set(p[0], p[1], '+', c)
+	}
+}
+
+func (a *ASCIIRenderer) Text(x, y int, text string, c RGB) {
+	for i, r := range []rune(text) {
+		a.set(x+i, y, r, c)
+	}
+}
+
+func (a *ASCIIRenderer) Flush(w io.Writer) error {
+	var buf bytes.Buffer
+	for y, row := range a.cells {
+		if !a.ansi {
+			buf.WriteString(strings.TrimRight(string(row), " "))
+			buf.WriteByte('\n')
+			continue
+		}
+		for x, r := range row {
+			if c := a.colors[y][x]; c != nil {
+				fmt.Fprintf(&buf, "\x1b[38;2;%d;%d;%dm%c\x1b[0m", c.R, c.G, c.B, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func CreateASCIIRenderer(ansi bool) *ASCIIRenderer {
+	return &ASCIIRenderer{ansi: ansi}
+}
+
+// 具体实现化角色：SVG 渲染器
+type SVGRenderer struct {
+	scale int // 一个格子对应的像素数
+	buf   bytes.Buffer
+}
+
+func (s *SVGRenderer) Begin(width, height int) {
+	s.buf.Reset()
+	fmt.Fprintf(&s.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width*s.scale, height*s.scale, width, height)
+}
+
+func (s *SVGRenderer) FillRect(x, y, width, height int, c RGB) {
+	fmt.Fprintf(&s.buf, `  <rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, width, height, c.Hex())
+}
+
+func (s *SVGRenderer) StrokeRect(x, y, width, height int, c RGB) {
+	fmt.Fprintf(&s.buf, `  <rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="0.25"/>`+"\n",
+		x, y, width, height, c.Hex())
+}
+
+func (s *SVGRenderer) Text(x, y int, text string, c RGB) {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(text))
+	fmt.Fprintf(&s.buf, `  <text x="%d" y="%d" font-size="1.5" font-family="sans-serif" fill="%s">%s</text>`+"\n",
+		x, y+1, c.Hex(), escaped.String())
+}
+
+func (s *SVGRenderer) Flush(w io.Writer) error {
+	s.buf.WriteString("</svg>\n")
+	_, err := w.Write(s.buf.Bytes())
+	return err
+}
+
+func CreateSVGRenderer(scale int) *SVGRenderer {
+	return &SVGRenderer{scale: scale}
+}
+
+// 具体实现化角色：PNG 渲染器
+// 标准库没有字体，Text 不输出任何内容
+type PNGRenderer struct {
+	scale int // 一个格子对应的像素数
+	img   *image.RGBA
+}
+
+func (p *PNGRenderer) Begin(width, height int) {
+	p.img = image.NewRGBA(image.Rect(0, 0, width*p.scale, height*p.scale))
+	draw.Draw(p.img, p.img.Bounds(), image.White, image.Point{}, draw.Src)
+}
+
+func (p *PNGRenderer) fill(r image.Rectangle, c RGB) {
+	draw.Draw(p.img, r, &image.Uniform{C: color.RGBA{c.R, c.G, c.B, 0xFF}}, image.Point{}, draw.Src)
+}
+
+func (p *PNGRenderer) FillRect(x, y, width, height int, c RGB) {
+	s := p.scale
+	p.fill(image.Rect(x*s, y*s, (x+width)*s, (y+height)*s), c)
+}
+
+func (p *PNGRenderer) StrokeRect(x, y, width, height int, c RGB) {
+	s := p.scale
+	line := s / 4
+	if line < 1 {
+		line = 1
+	}
+	r := image.Rect(x*s, y*s, (x+width)*s, (y+height)*s)
+	p.fill(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+line), c)
+	p.fill(image.Rect(r.Min.X, r.Max.Y-line, r.Max.X, r.Max.Y), c)
+	p.fill(image.Rect(r.Min.X, r.Min.Y, r.Min.X+line, r.Max.Y), c)
+	p.fill(image.Rect(r.Max.X-line, r.Min.Y, r.Max.X, r.Max.Y), c)
+}
+
+func (p *PNGRenderer) Text(x, y int, text string, c RGB) {
+}
+
+func (p *PNGRenderer) Flush(w io.Writer) error {
+	return png.Encode(w, p.img)
+}
+
+func CreatePNGRenderer(scale int) *PNGRenderer {
+	return &PNGRenderer{scale: scale}
+}
+
+// 挎包：提手加包身
+func (h *HandBag) Draw(r Renderer) {
+	fill := fillOf(h.bag)
+	r.Begin(40, 22)
+	r.StrokeRect(12, 1, 16, 8, outlineRGB)
+	r.FillRect(4, 7, 32, 12, fill)
+	r.StrokeRect(4, 7, 32, 12, outlineRGB)
+	r.Text(4, 20, h.GetName(), outlineRGB)
+}
+
+// 钱包：包身加翻盖和搭扣
+func (h *Wallet) Draw(r Renderer) {
+	fill := fillOf(h.bag)
+	r.Begin(40, 16)
+	r.FillRect(4, 1, 32, 12, fill)
+	r.FillRect(4, 1, 32, 5, darken(fill, 0.8))
+	r.FillRect(19, 5, 2, 2, outlineRGB)
+	r.StrokeRect(4, 1, 32, 12, outlineRGB)
+	r.Text(4, 14, h.GetName(), outlineRGB)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testProducts() map[string]Drawable {
+	return map[string]Drawable{
+		"handbag": CreateHandBag(CreateBag(&Yellow{})),
+		"wallet":  CreateWallet(CreateBagOf(&Red{}, &Leather{}, &Small{})),
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from golden file\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	renderers := map[string]func() Renderer{
+		"ascii": func() Renderer { return CreateASCIIRenderer(false) },
+		"svg":   func() Renderer { return CreateSVGRenderer(10) },
+	}
+	for product, d := range testProducts() {
+		for format, create := range renderers {
+			t.Run(product+"."+format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Render(d, create(), &buf); err != nil {
+					t.Fatal(err)
+				}
+				checkGolden(t, product+"."+format, buf.Bytes())
+			})
+		}
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	const scale = 4
+	white := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	rgba := func(c RGB) color.RGBA { return color.RGBA{c.R, c.G, c.B, 0xFF} }
+	// 格子中心和格子左上角的像素；描边只有 scale/4 个像素宽，要在左上角检查
+	center := func(x, y int) image.Point { return image.Pt(x*scale+scale/2, y*scale+scale/2) }
+	corner := func(x, y int) image.Point { return image.Pt(x*scale, y*scale) }
+	tests := []struct {
+		product string
+		width   int
+		height  int
+		pixels  map[image.Point]color.RGBA // 像素坐标 -> 颜色
+	}{
+		{"handbag", 40, 22, map[image.Point]color.RGBA{
+			center(1, 1):   white,              // 背景
+			center(20, 12): rgba(yellow.RGB()), // 包身
+			corner(12, 4):  rgba(outlineRGB),   // 提手
+			center(20, 4):  white,              // 提手中间
+			center(5, 20):  white,              // PNG 不输出文字
+		}},
+		{"wallet", 40, 16, map[image.Point]color.RGBA{
+			center(1, 1):  white,
+			center(10, 9): rgba(red.RGB()),              // 包身
+			center(10, 3): rgba(darken(red.RGB(), 0.8)), // 翻盖
+			center(19, 5): rgba(outlineRGB),             // 搭扣
+			corner(4, 8):  rgba(outlineRGB),             // 包身左边的描边
+		}},
+	}
+	products := testProducts()
+	for _, tt := range tests {
+		t.Run(tt.product, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(products[tt.product], CreatePNGRenderer(scale), &buf); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.width*scale || b.Dy() != tt.height*scale {
+				t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.width*scale, tt.height*scale)
+			}
+			for p, want := range tt.pixels {
+				got := color.RGBAModel.Convert(img.At(p.X, p.Y)).(color.RGBA)
+				if got != want {
+					t.Errorf("pixel %v = %v, want %v", p, got, want)
+				}
+			}
+		})
+	}
+}
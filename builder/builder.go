@@ -26,13 +26,118 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 )
 
 func main() {
 	builder := CreateConcreteDecorator1() // 建造者
 	m := CreateProjectManager(builder)    // 指挥者
 	m.Decorate()
+
+	// 流式建造者：Build 时校验必需部件和部件之间的兼容性
+	parlour, err := CreateParlourBuilder().
+		Wall("wall3", 400).
+		TV("tv3", 180).
+		Sofa("sofa3", 240).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	parlour.Show()
+	if err := parlour.SetTV("tv5"); err != nil {
+		fmt.Println(err) // 建造完成的客厅不可修改
+	}
+
+	// 装修配方：按 JSON 配方中的步骤装修
+	recipe, err := ParseRecipe(strings.NewReader(`{
+		"name": "modern",
+		"decorator": "decorator2",
+		"vars": {"budget": "normal"},
+		"steps": [
+			{"step": "wall", "params": {"name": "marble wall", "width": 420}},
+			{"step": "tv", "depends_on": ["wall"]},
+			{"id": "oled", "step": "tv", "params": {"name": "oled tv", "width": 190},
+				"when": {"var": "budget", "equals": "high"}, "depends_on": ["tv"]},
+			{"step": "sofa"}
+		]
+	}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	rm, err := recipe.Compile(map[string]string{"budget": "high"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	rm.Decorate()
+
+	// 报价与工期
+	quote, err := rm.Quote()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	quote.Print(os.Stdout)
+	schedule, err := rm.Schedule(time.Date(2020, 5, 1, 8, 0, 0, 0, time.Local))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	schedule.WriteGantt(os.Stdout, time.Hour)
+
+	// 整套房子：按户型图建造各个房间
+	plan, err := ParseFloorPlan(strings.NewReader(`{
+		"name": "two-bedroom",
+		"rooms": [
+			{"name": "living room", "type": "parlour", "style": "1"},
+			{"name": "master bedroom", "type": "bedroom", "style": "2"},
+			{"name": "kitchen", "type": "kitchen", "style": "1"},
+			{"name": "bathroom", "type": "bathroom", "style": "1"}
+		]
+	}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	house, err := CreateHouseDirector(plan).Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	house.Show()
+
+	// 回滚：验收失败时，按相反顺序撤销已完成的步骤
+	tx := CreateProjectManager(CreateConcreteDecorator1())
+	tx.SetSteps(append(DefaultSteps(), &Step{
+		Name:      "inspect",
+		DependsOn: []string{"tv", "sofa"},
+		Run:       func(d Decorator) error { return errors.New("inspection failed") },
+	})...)
+	tx.Decorate()
+
+	// 快照：以保存的设计为模板，只修改电视
+	var saved bytes.Buffer
+	parlour.Snapshot().Save(&saved)
+	snapshot, err := LoadSnapshot(&saved)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	tweaked, err := CreateParlourBuilderFrom(snapshot).TV("tv4", 200).Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range DiffParlours(parlour, tweaked) {
+		fmt.Println(d)
+	}
 }
 
 /*
@@ -47,18 +152,75 @@ type Parlour struct {
 	wall string // 墙
 	tv   string // 电视
 	sofa string // 沙发
+
+	wallWidth int  // 墙宽，单位：厘米，0 表示未知
+	tvWidth   int  // 电视宽，单位：厘米，0 表示未知
+	sofaWidth int  // 沙发宽，单位：厘米，0 表示未知
+	frozen    bool // 由 ParlourBuilder 建造完成后不可修改
+
+	wallHistory []partState // 墙的历史，用于回滚
+	tvHistory   []partState // 电视的历史，用于回滚
+	sofaHistory []partState // 沙发的历史，用于回滚
+}
+
+var ErrFrozen = errors.New("parlour is immutable after Build")
+
+// 建造完成的客厅返回 ErrFrozen
+func (p *Parlour) SetWall(wall string) error {
+	return p.setPart("wall", wall, p.wallWidth)
+}
+
+func (p *Parlour) SetTV(tv string) error {
+	return p.setPart("tv", tv, p.tvWidth)
 }
 
-func (p *Parlour) SetWall(wall string) {
-	p.wall = wall
+func (p *Parlour) SetSofa(sofa string) error {
+	return p.setPart("sofa", sofa, p.sofaWidth)
 }
 
-func (p *Parlour) SetTV(tv string) {
-	p.tv = tv
+func (p *Parlour) checkFrozen() error {
+	if p.frozen {
+		return ErrFrozen
+	}
+	return nil
 }
 
-func (p *Parlour) SetSofa(sofa string) {
-	p.sofa = sofa
+func (p *Parlour) Wall() string {
+	return p.wall
+}
+
+func (p *Parlour) TV() string {
+	return p.tv
+}
+
+func (p *Parlour) Sofa() string {
+	return p.sofa
+}
+
+// 按部件名称设置部件及其宽度，原来的值记入历史
+func (p *Parlour) setPart(part, name string, width int) error {
+	if err := p.checkFrozen(); err != nil {
+		return err
+	}
+	n, w, history, err := p.fields(part)
+	if err != nil {
+		return err
+	}
+	*history = append(*history, partState{*n, *w})
+	*n, *w = name, width
+	return nil
+}
+
+func (p *Parlour) fields(part string) (*string, *int, *[]partState, error) {
+	switch part {
+	case "wall":
+		return &p.wall, &p.wallWidth, &p.wallHistory, nil
+	case "tv":
+		return &p.tv, &p.tvWidth, &p.tvHistory, nil
+	case "sofa":
+		return &p.sofa, &p.sofaWidth, &p.sofaHistory, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unknown part %q", part)
 }
 
 func (p *Parlour) Show() {
@@ -75,6 +237,11 @@ type Decorator interface {
 	Show()
 }
 
+// 可以按参数建造部件的装修工人
+type PartDecorator interface {
+	BuildPart(part, name string, width int) error
+}
+
 // 具体建造者：装修工人1
 type ConcreteDecorator1 struct {
 	parlour *Parlour
@@ -85,11 +252,15 @@ func (c *ConcreteDecorator1) BuildWall() {
 }
 
 func (c *ConcreteDecorator1) BuildTV() {
-	c.parlour.SetSofa("sofa1")
+	c.parlour.SetTV("tv1")
 }
 
 func (c *ConcreteDecorator1) BuildSofa() {
-	c.parlour.SetTV("tv1")
+	c.parlour.SetSofa("sofa1")
+}
+
+func (c *ConcreteDecorator1) BuildPart(part, name string, width int) error {
+	return c.parlour.setPart(part, name, width)
 }
 
 func (c *ConcreteDecorator1) Show() {
@@ -114,11 +285,15 @@ func (c *ConcreteDecorator2) BuildWall() {
 }
 
 func (c *ConcreteDecorator2) BuildTV() {
-	c.parlour.SetSofa("sofa2")
+	c.parlour.SetTV("tv2")
 }
 
 func (c *ConcreteDecorator2) BuildSofa() {
-	c.parlour.SetTV("tv2")
+	c.parlour.SetSofa("sofa2")
+}
+
+func (c *ConcreteDecorator2) BuildPart(part, name string, width int) error {
+	return c.parlour.setPart(part, name, width)
 }
 
 func (c *ConcreteDecorator2) Show() {
@@ -129,23 +304,80 @@ func CreateConcreteDecorator2() *ConcreteDecorator2 {
 	return &ConcreteDecorator2{parlour: &Parlour{}}
 }
 
+// 装修步骤
+type Step struct {
+	Name      string
+	Part      string   // 建造的部件，用于查找成本
+	DependsOn []string // 必须先完成的步骤
+	Run       func(d Decorator) error
+	Undo      func(d Decorator) error // 补偿操作，后续步骤失败时调用，可以为空
+}
+
+// 默认步骤：沙发、墙、电视，电视必须在墙之后
+func DefaultSteps() []*Step {
+	return []*Step{
+		{Name: "sofa", Part: "sofa", Run: func(d Decorator) error { d.BuildSofa(); return nil }, Undo: UndoPart("sofa")},
+		{Name: "wall", Part: "wall", Run: func(d Decorator) error { d.BuildWall(); return nil }, Undo: UndoPart("wall")},
+		{Name: "tv", Part: "tv", DependsOn: []string{"wall"}, Run: func(d Decorator) error { d.BuildTV(); return nil }, Undo: UndoPart("tv")},
+	}
+}
+
 // 指挥者：项目经理
 type ProjectManager struct {
-	builder Decorator
+	builder    Decorator
+	steps      []*Step
+	workers    int // 同时执行的步骤数上限，0 表示不限
+	labourRate int // 每工时的人工费，单位：元
+	crewSize   int // 可以同时调配的工人数，0 表示按步骤的最大需求
 }
 
 func (p *ProjectManager) ProjectManager(builder Decorator) {
 	p.builder = builder
 }
 
+// 设置装修步骤，未设置时使用默认步骤
+func (p *ProjectManager) SetSteps(steps ...*Step) {
+	p.steps = steps
+}
+
+func (p *ProjectManager) SetWorkers(workers int) {
+	p.workers = workers
+}
+
+func (p *ProjectManager) SetLabourRate(rate int) {
+	p.labourRate = rate
+}
+
+func (p *ProjectManager) SetCrewSize(size int) {
+	p.crewSize = size
+}
+
+// 当前的装修步骤
+func (p *ProjectManager) plan() []*Step {
+	if len(p.steps) == 0 {
+		return DefaultSteps()
+	}
+	return p.steps
+}
+
 //产品构建与组装方法
 func (p *ProjectManager) Decorate() {
-	p.builder.BuildSofa()
-	p.builder.BuildTV()
-	p.builder.BuildWall()
+	if _, err := p.Execute(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 按依赖关系执行装修步骤，互不依赖的步骤并发执行；
+// 某一步失败时，依赖它的步骤被跳过，已完成的步骤按相反顺序回滚
+func (p *ProjectManager) Execute() (*ExecutionReport, error) {
+	report, err := RunSteps(p.builder, p.plan(), p.workers)
+	if err != nil {
+		return report, err
+	}
 	p.builder.Show()
+	return report, nil
 }
 
 func CreateProjectManager(builder Decorator) *ProjectManager {
-	return &ProjectManager{builder: builder}
+	return &ProjectManager{builder: builder, labourRate: defaultLabourRate}
 }
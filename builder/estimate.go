@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+	报价与工期：每个装修步骤都有材料费、工时和需要的工人数，项目经理在开工前据此给出分项报价，
+	并在工人数的限制下按依赖关系排出每个步骤的开始和结束时间。
+	工期按连续的工作小时计算，一个步骤的时长为 工时 / 工人数。
+	成本默认取自 DefaultCosts，具体建造者可以实现 CostDecorator 覆盖价格。
+*/
+
+const defaultLabourRate = 80 // 默认每工时的人工费，单位：元
+
+// 步骤成本
+type StepCost struct {
+	Material    int     // 材料费，单位：元
+	LabourHours float64 // 工时，单位：人·小时
+	Crew        int     // 需要的工人数
+}
+
+// 时长：工时 / 工人数
+func (c StepCost) Duration() time.Duration {
+	if c.Crew <= 0 {
+		return 0
+	}
+	return time.Duration(c.LabourHours / float64(c.Crew) * float64(time.Hour))
+}
+
+// 各部件的默认成本
+var DefaultCosts = map[string]StepCost{
+	"wall": {Material: 3000, LabourHours: 16, Crew: 2},
+	"tv":   {Material: 5000, LabourHours: 2, Crew: 1},
+	"sofa": {Material: 8000, LabourHours: 3, Crew: 2},
+}
+
+// 可以覆盖价格的装修工人
+type CostDecorator interface {
+	Cost(part string, base StepCost) StepCost
+}
+
+func (p *ProjectManager) stepCost(step *Step) (StepCost, error) {
+	cost, ok := DefaultCosts[step.Part]
+	if c, isCoster := p.builder.(CostDecorator); isCoster {
+		cost, ok = c.Cost(step.Part, cost), true
+	}
+	if !ok {
+		return StepCost{}, fmt.Errorf("step %s: no cost for part %q", step.Name, step.Part)
+	}
+	if cost.Crew <= 0 {
+		return StepCost{}, fmt.Errorf("step %s: crew must be positive, got %d", step.Name, cost.Crew)
+	}
+	return cost, nil
+}
+
+// 报价单条目
+type QuoteItem struct {
+	Step string
+	StepCost
+	Labour int // 人工费，单位：元
+	Total  int // 小计，单位：元
+}
+
+// 报价单
+type Quote struct {
+	Items    []*QuoteItem
+	Material int
+	Labour   int
+	Total    int
+}
+
+func (q *Quote) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "step\tmaterial\thours\tcrew\tlabour\ttotal\t")
+	for _, it := range q.Items {
+		fmt.Fprintf(tw, "%s\t%d\t%g\t%d\t%d\t%d\t\n", it.Step, it.Material, it.LabourHours, it.Crew, it.Labour, it.Total)
+	}
+	fmt.Fprintf(tw, "total\t%d\t\t\t%d\t%d\t\n", q.Material, q.Labour, q.Total)
+	tw.Flush()
+}
+
+// 分项报价
+func (p *ProjectManager) Quote() (*Quote, error) {
+	q := &Quote{}
+	for _, step := range p.plan() {
+		cost, err := p.stepCost(step)
+		if err != nil {
+			return nil, err
+		}
+		labour := int(math.Round(cost.LabourHours * float64(p.labourRate)))
+		item := &QuoteItem{Step: step.Name, StepCost: cost, Labour: labour, Total: cost.Material + labour}
+		q.Items = append(q.Items, item)
+		q.Material += item.Material
+		q.Labour += item.Labour
+		q.Total += item.Total
+	}
+	return q, nil
+}
+
+// 排期条目
+type ScheduledStep struct {
+	Step  string
+	Crew  int
+	Start time.Time
+	End   time.Time
+}
+
+// 排期
+type Schedule struct {
+	Start time.Time
+	End   time.Time
+	Steps []*ScheduledStep // 按开始时间排序
+}
+
+// 从 start 开始排期：依赖的步骤全部结束、且空闲工人足够时，步骤才能开始；
+// 同时可以开始的步骤按声明顺序优先
+func (p *ProjectManager) Schedule(start time.Time) (*Schedule, error) {
+	steps := p.plan()
+	if err := CheckSteps(steps); err != nil {
+		return nil, err
+	}
+	costs := map[string]StepCost{}
+	capacity := p.crewSize
+	for _, step := range steps {
+		cost, err := p.stepCost(step)
+		if err != nil {
+			return nil, err
+		}
+		costs[step.Name] = cost
+		if p.crewSize == 0 && cost.Crew > capacity {
+			capacity = cost.Crew
+		}
+	}
+	for _, step := range steps {
+		if costs[step.Name].Crew > capacity {
+			return nil, fmt.Errorf("step %s needs %d crew, only %d available", step.Name, costs[step.Name].Crew, capacity)
+		}
+	}
+
+	s := &Schedule{Start: start, End: start}
+	finished := map[string]time.Time{}
+	var running []*ScheduledStep
+	now, free := start, capacity
+	for len(finished) < len(steps) {
+		for _, step := range steps {
+			if _, ok := finished[step.Name]; ok || scheduled(s, step.Name) {
+				continue
+			}
+			if !depsFinished(step, finished) || costs[step.Name].Crew > free {
+				continue
+			}
+			cost := costs[step.Name]
+			ss := &ScheduledStep{Step: step.Name, Crew: cost.Crew, Start: now, End: now.Add(cost.Duration())}
+			s.Steps = append(s.Steps, ss)
+			running = append(running, ss)
+			free -= cost.Crew
+		}
+		// 推进到最早结束的步骤
+		sort.Slice(running, func(i, j int) bool { return running[i].End.Before(running[j].End) })
+		next := running[0]
+		running = running[1:]
+		now = next.End
+		free += next.Crew
+		finished[next.Step] = next.End
+		if next.End.After(s.End) {
+			s.End = next.End
+		}
+	}
+	return s, nil
+}
+
+func scheduled(s *Schedule, name string) bool {
+	for _, ss := range s.Steps {
+		if ss.Step == name {
+			return true
+		}
+	}
+	return false
+}
+
+func depsFinished(step *Step, finished map[string]time.Time) bool {
+	for _, dep := range step.DependsOn {
+		if _, ok := finished[dep]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 导出 CSV
+func (s *Schedule) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"step", "crew", "start", "end", "hours"})
+	for _, ss := range s.Steps {
+		cw.Write([]string{
+			ss.Step,
+			strconv.Itoa(ss.Crew),
+			ss.Start.Format(time.RFC3339),
+			ss.End.Format(time.RFC3339),
+			strconv.FormatFloat(ss.End.Sub(ss.Start).Hours(), 'f', -1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// 文本甘特图，每个字符代表 scale 的时长
+func (s *Schedule) WriteGantt(w io.Writer, scale time.Duration) error {
+	if scale <= 0 {
+		scale = time.Hour
+	}
+	width := 0
+	for _, ss := range s.Steps {
+		if len(ss.Step) > width {
+			width = len(ss.Step)
+		}
+	}
+	cells := int(math.Ceil(float64(s.End.Sub(s.Start)) / float64(scale)))
+	for _, ss := range s.Steps {
+		from := int(ss.Start.Sub(s.Start) / scale)
+		to := int(math.Ceil(float64(ss.End.Sub(s.Start)) / float64(scale)))
+		bar := strings.Repeat(" ", from) + strings.Repeat("#", to-from) + strings.Repeat(" ", cells-to)
+		_, err := fmt.Fprintf(w, "%-*s |%s| %s - %s\n", width, ss.Step, bar,
+			ss.Start.Format("01-02 15:04"), ss.End.Format("01-02 15:04"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 装修工人2 使用更好的材料，材料费上浮 20%
+func (c *ConcreteDecorator2) Cost(part string, base StepCost) StepCost {
+	base.Material = base.Material * 12 / 10
+	return base
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+	按依赖关系执行装修步骤：有的步骤依赖其他步骤，比如电视必须在墙之后安装。
+	步骤按拓扑顺序执行，互不依赖的步骤并发执行，执行前检查依赖是否存在、是否有环。
+	某一步失败时，所有直接或间接依赖它的步骤都会被跳过，已完成的步骤按相反顺序回滚，
+	执行报告列出成功、失败和跳过的步骤以及执行过的补偿操作。
+	互不依赖的步骤不能建造同一个部件，否则它们会同时修改客厅的同一个部件，执行前检查会拒绝这样的步骤。
+*/
+
+// 执行报告，各列表按步骤声明的顺序排列
+type ExecutionReport struct {
+	Completed  []string
+	Failed     []*StepError
+	Skipped    []string        // 因依赖的步骤失败或被跳过而未执行
+	RolledBack []*Compensation // 按执行顺序排列
+}
+
+// 失败或跳过时返回的错误
+func (r *ExecutionReport) Err() error {
+	if len(r.Failed) == 0 && len(r.Skipped) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, f := range r.Failed {
+		errs = append(errs, f)
+	}
+	if len(r.Skipped) > 0 {
+		errs = append(errs, fmt.Errorf("skipped steps: %s", strings.Join(r.Skipped, ", ")))
+	}
+	if len(r.RolledBack) > 0 {
+		errs = append(errs, fmt.Errorf("rolled back: %s", compensationList(r.RolledBack)))
+	}
+	return errors.Join(errs...)
+}
+
+// 步骤错误
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %s: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// 检查步骤名称是否重复、依赖是否存在、是否有环，以及互不依赖的步骤是否建造同一个部件
+func CheckSteps(steps []*Step) error {
+	var errs []error
+	index := map[string]*Step{}
+	for _, s := range steps {
+		if _, ok := index[s.Name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate step %q", s.Name))
+		}
+		index[s.Name] = s
+	}
+	for _, s := range steps {
+		for _, dep := range s.DependsOn {
+			if _, ok := index[dep]; !ok {
+				errs = append(errs, fmt.Errorf("step %q depends on unknown step %q", s.Name, dep))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if cycle := findCycle(steps, index); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return checkParts(steps, index)
+}
+
+// 建造同一个部件的步骤必须直接或间接地互相依赖，否则会并发执行
+func checkParts(steps []*Step, index map[string]*Step) error {
+	var errs []error
+	for i, a := range steps {
+		for _, b := range steps[i+1:] {
+			if a.Part == "" || a.Part != b.Part {
+				continue
+			}
+			if !dependsOn(index, a.Name, b.Name) && !dependsOn(index, b.Name, a.Name) {
+				errs = append(errs, fmt.Errorf("steps %q and %q both build %s, one must depend on the other", a.Name, b.Name, a.Part))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// from 是否直接或间接依赖 to，调用前已经检查过没有环
+func dependsOn(index map[string]*Step, from, to string) bool {
+	for _, dep := range index[from].DependsOn {
+		if dep == to || dependsOn(index, dep, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// 深度优先查找环，返回环上的步骤，首尾相同
+func findCycle(steps []*Step, index map[string]*Step) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range index[name].DependsOn {
+			switch state[dep] {
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						return append(append([]string(nil), path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, s := range steps {
+		if state[s.Name] == unvisited {
+			if cycle := visit(s.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// 执行步骤，workers 为同时执行的步骤数上限，0 表示不限；有步骤失败时回滚已完成的步骤
+func RunSteps(d Decorator, steps []*Step, workers int) (*ExecutionReport, error) {
+	if err := CheckSteps(steps); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		err     error
+		skipped bool
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = map[string]*result{}
+		done    = map[string]chan struct{}{}
+		sem     chan struct{}
+		order   []*Step // 完成顺序
+	)
+	if workers > 0 {
+		sem = make(chan struct{}, workers)
+	}
+	for _, s := range steps {
+		done[s.Name] = make(chan struct{})
+	}
+	for _, s := range steps {
+		wg.Add(1)
+		go func(s *Step) {
+			defer wg.Done()
+			defer close(done[s.Name])
+			ok := true
+			for _, dep := range s.DependsOn {
+				<-done[dep]
+				mu.Lock()
+				r := results[dep]
+				mu.Unlock()
+				if r.err != nil || r.skipped {
+					ok = false
+				}
+			}
+			r := &result{skipped: !ok}
+			if ok {
+				if sem != nil {
+					sem <- struct{}{}
+				}
+				r.err = s.Run(d)
+				if sem != nil {
+					<-sem
+				}
+			}
+			mu.Lock()
+			results[s.Name] = r
+			if ok && r.err == nil {
+				order = append(order, s)
+			}
+			mu.Unlock()
+		}(s)
+	}
+	wg.Wait()
+
+	report := &ExecutionReport{}
+	for _, s := range steps {
+		switch r := results[s.Name]; {
+		case r.skipped:
+			report.Skipped = append(report.Skipped, s.Name)
+		case r.err != nil:
+			report.Failed = append(report.Failed, &StepError{Step: s.Name, Err: r.err})
+		default:
+			report.Completed = append(report.Completed, s.Name)
+		}
+	}
+	if len(report.Failed) > 0 {
+		report.RolledBack = rollback(d, order)
+	}
+	return report, report.Err()
+}
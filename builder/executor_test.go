@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 什么都不做的步骤
+func noopStep(name, part string, deps ...string) *Step {
+	return &Step{Name: name, Part: part, DependsOn: deps, Run: func(d Decorator) error { return nil }}
+}
+
+func TestCheckStepsCycle(t *testing.T) {
+	err := CheckSteps([]*Step{
+		noopStep("plan", ""),
+		noopStep("a", "", "plan", "b"),
+		noopStep("b", "", "c"),
+		noopStep("c", "", "a"),
+	})
+	if err == nil || err.Error() != "dependency cycle: a -> b -> c -> a" {
+		t.Fatalf("CheckSteps() = %v, want the cycle a -> b -> c -> a", err)
+	}
+}
+
+func TestCheckStepsUnknownAndDuplicate(t *testing.T) {
+	err := CheckSteps([]*Step{
+		noopStep("wall", "wall"),
+		noopStep("wall", "wall"),
+		noopStep("tv", "tv", "mount"),
+	})
+	for _, want := range []string{`duplicate step "wall"`, `step "tv" depends on unknown step "mount"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckSteps() = %v, want %q", err, want)
+		}
+	}
+}
+
+// 建造同一个部件的步骤必须直接或间接地互相依赖
+func TestCheckStepsPartConflict(t *testing.T) {
+	err := CheckSteps([]*Step{
+		noopStep("wall", "wall"),
+		noopStep("tv", "tv", "wall"),
+		noopStep("bigger tv", "tv", "wall"),
+	})
+	want := `steps "tv" and "bigger tv" both build tv, one must depend on the other`
+	if err == nil || err.Error() != want {
+		t.Fatalf("CheckSteps() = %v, want %q", err, want)
+	}
+
+	// 间接依赖也可以保证先后顺序
+	err = CheckSteps([]*Step{
+		noopStep("wall", "wall"),
+		noopStep("tv", "tv", "wall"),
+		noopStep("sofa", "sofa", "tv"),
+		noopStep("bigger tv", "tv", "sofa"),
+	})
+	if err != nil {
+		t.Fatalf("CheckSteps() with an indirect dependency = %v", err)
+	}
+}
+
+// 失败步骤的直接和间接后继都被跳过，互不相关的步骤照常执行
+func TestRunStepsSkipsDependents(t *testing.T) {
+	broken := errors.New("no power")
+	steps := []*Step{
+		noopStep("wall", "wall"),
+		{Name: "socket", DependsOn: []string{"wall"}, Run: func(d Decorator) error { return broken }},
+		noopStep("tv", "tv", "socket"),
+		noopStep("speakers", "", "tv"),
+		noopStep("sofa", "sofa"),
+	}
+	report, err := RunSteps(CreateConcreteDecorator1(), steps, 0)
+	if !errors.Is(err, broken) {
+		t.Fatalf("RunSteps() = %v, want %v", err, broken)
+	}
+	if got := strings.Join(report.Completed, ","); got != "wall,sofa" {
+		t.Errorf("completed %s, want wall,sofa", got)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Step != "socket" {
+		t.Errorf("failed %v, want socket", report.Failed)
+	}
+	if got := strings.Join(report.Skipped, ","); got != "tv,speakers" {
+		t.Errorf("skipped %s, want tv,speakers", got)
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+/*
+	整套房子的装修：除了客厅，还有卧室、厨房和卫生间，它们是不同的产品，各自有自己的抽象建造者和具体建造者。
+	房屋指挥者（HouseDirector）按户型图逐个房间选择建造者、按顺序建造，最后汇总每个房间的装修结果。
+	户型图中的 style 选择具体建造者的款式，客厅的款式对应注册表中的 decorator1、decorator2。
+*/
+
+// 房间汇总
+type RoomSummary struct {
+	Name  string         `json:"name"`
+	Type  string         `json:"type"`
+	Parts []*PartSummary `json:"parts"`
+}
+
+type PartSummary struct {
+	Part  string `json:"part"`
+	Value string `json:"value"`
+}
+
+func (r *RoomSummary) Show() {
+	fmt.Printf("[%s] %s\n", r.Type, r.Name)
+	for _, p := range r.Parts {
+		fmt.Println("  "+p.Part+": ", p.Value)
+	}
+}
+
+// 可以汇总装修结果的建造者
+type Summarizer interface {
+	Summary() []*PartSummary
+}
+
+func (c *ConcreteDecorator1) Summary() []*PartSummary {
+	return c.parlour.summary()
+}
+
+func (c *ConcreteDecorator2) Summary() []*PartSummary {
+	return c.parlour.summary()
+}
+
+func (p *Parlour) summary() []*PartSummary {
+	return []*PartSummary{{"wall", p.wall}, {"tv", p.tv}, {"sofa", p.sofa}}
+}
+
+// 产品：卧室
+type Bedroom struct {
+	bed      string // 床
+	wardrobe string // 衣柜
+	lamp     string // 台灯
+}
+
+func (b *Bedroom) SetBed(bed string) {
+	b.bed = bed
+}
+
+func (b *Bedroom) SetWardrobe(wardrobe string) {
+	b.wardrobe = wardrobe
+}
+
+func (b *Bedroom) SetLamp(lamp string) {
+	b.lamp = lamp
+}
+
+// 抽象建造者：卧室装修工人
+type BedroomDecorator interface {
+	BuildBed()
+	BuildWardrobe()
+	BuildLamp()
+	Summarizer
+}
+
+// 具体建造者：卧室装修工人
+type ConcreteBedroomDecorator struct {
+	style   string
+	bedroom *Bedroom
+}
+
+func (c *ConcreteBedroomDecorator) BuildBed() {
+	c.bedroom.SetBed("bed" + c.style)
+}
+
+func (c *ConcreteBedroomDecorator) BuildWardrobe() {
+	c.bedroom.SetWardrobe("wardrobe" + c.style)
+}
+
+func (c *ConcreteBedroomDecorator) BuildLamp() {
+	c.bedroom.SetLamp("lamp" + c.style)
+}
+
+func (c *ConcreteBedroomDecorator) Summary() []*PartSummary {
+	b := c.bedroom
+	return []*PartSummary{{"bed", b.bed}, {"wardrobe", b.wardrobe}, {"lamp", b.lamp}}
+}
+
+func CreateConcreteBedroomDecorator(style string) *ConcreteBedroomDecorator {
+	return &ConcreteBedroomDecorator{style: style, bedroom: &Bedroom{}}
+}
+
+// 产品：厨房
+type Kitchen struct {
+	cabinet string // 橱柜
+	stove   string // 灶台
+	fridge  string // 冰箱
+}
+
+func (k *Kitchen) SetCabinet(cabinet string) {
+	k.cabinet = cabinet
+}
+
+func (k *Kitchen) SetStove(stove string) {
+	k.stove = stove
+}
+
+func (k *Kitchen) SetFridge(fridge string) {
+	k.fridge = fridge
+}
+
+// 抽象建造者：厨房装修工人
+type KitchenDecorator interface {
+	BuildCabinet()
+	BuildStove()
+	BuildFridge()
+	Summarizer
+}
+
+// 具体建造者：厨房装修工人
+type ConcreteKitchenDecorator struct {
+	style   string
+	kitchen *Kitchen
+}
+
+func (c *ConcreteKitchenDecorator) BuildCabinet() {
+	c.kitchen.SetCabinet("cabinet" + c.style)
+}
+
+func (c *ConcreteKitchenDecorator) BuildStove() {
+	c.kitchen.SetStove("stove" + c.style)
+}
+
+func (c *ConcreteKitchenDecorator) BuildFridge() {
+	c.kitchen.SetFridge("fridge" + c.style)
+}
+
+func (c *ConcreteKitchenDecorator) Summary() []*PartSummary {
+	k := c.kitchen
+	return []*PartSummary{{"cabinet", k.cabinet}, {"stove", k.stove}, {"fridge", k.fridge}}
+}
+
+func CreateConcreteKitchenDecorator(style string) *ConcreteKitchenDecorator {
+	return &ConcreteKitchenDecorator{style: style, kitchen: &Kitchen{}}
+}
+
+// 产品：卫生间
+type Bathroom struct {
+	tiles  string // 瓷砖
+	shower string // 淋浴
+	toilet string // 马桶
+}
+
+func (b *Bathroom) SetTiles(tiles string) {
+	b.tiles = tiles
+}
+
+func (b *Bathroom) SetShower(shower string) {
+	b.shower = shower
+}
+
+func (b *Bathroom) SetToilet(toilet string) {
+	b.toilet = toilet
+}
+
+// 抽象建造者：卫生间装修工人
+type BathroomDecorator interface {
+	BuildTiles()
+	BuildShower()
+	BuildToilet()
+	Summarizer
+}
+
+// 具体建造者：卫生间装修工人
+type ConcreteBathroomDecorator struct {
+	style    string
+	bathroom *Bathroom
+}
+
+func (c *ConcreteBathroomDecorator) BuildTiles() {
+	c.bathroom.SetTiles("tiles" + c.style)
+}
+
+func (c *ConcreteBathroomDecorator) BuildShower() {
+	c.bathroom.SetShower("shower" + c.style)
+}
+
+func (c *ConcreteBathroomDecorator) BuildToilet() {
+	c.bathroom.SetToilet("toilet" + c.style)
+}
+
+func (c *ConcreteBathroomDecorator) Summary() []*PartSummary {
+	b := c.bathroom
+	return []*PartSummary{{"tiles", b.tiles}, {"shower", b.shower}, {"toilet", b.toilet}}
+}
+
+func CreateConcreteBathroomDecorator(style string) *ConcreteBathroomDecorator {
+	return &ConcreteBathroomDecorator{style: style, bathroom: &Bathroom{}}
+}
+
+// 户型图
+type FloorPlan struct {
+	Name  string      `json:"name"`
+	Rooms []*RoomSpec `json:"rooms"`
+}
+
+// 户型图中的房间
+type RoomSpec struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // parlour、bedroom、kitchen 或 bathroom
+	Style string `json:"style"` // 具体建造者的款式
+}
+
+func ParseFloorPlan(r io.Reader) (*FloorPlan, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	plan := &FloorPlan{}
+	if err := dec.Decode(plan); err != nil {
+		return nil, fmt.Errorf("parse floor plan: %w", err)
+	}
+	return plan, nil
+}
+
+func LoadFloorPlan(path string) (*FloorPlan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseFloorPlan(f)
+}
+
+// 房子：各个房间的汇总
+type House struct {
+	Name  string         `json:"name"`
+	Rooms []*RoomSummary `json:"rooms"`
+}
+
+func (h *House) Show() {
+	fmt.Println("house: ", h.Name)
+	for _, r := range h.Rooms {
+		r.Show()
+	}
+}
+
+func (h *House) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h)
+}
+
+// 指挥者：房屋项目经理
+type HouseDirector struct {
+	plan *FloorPlan
+}
+
+// 按户型图建造整套房子，任何一个房间无法建造时返回错误
+func (h *HouseDirector) Build() (*House, error) {
+	var errs []error
+	house := &House{Name: h.plan.Name}
+	for i, spec := range h.plan.Rooms {
+		parts, err := h.buildRoom(spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("room %d (%s): %w", i+1, spec.Name, err))
+			continue
+		}
+		house.Rooms = append(house.Rooms, &RoomSummary{Name: spec.Name, Type: spec.Type, Parts: parts})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return house, nil
+}
+
+func (h *HouseDirector) buildRoom(spec *RoomSpec) ([]*PartSummary, error) {
+	if spec.Style == "" {
+		return nil, errors.New("style is required")
+	}
+	switch spec.Type {
+	case "parlour":
+		d, ok := LookupDecorator("decorator" + spec.Style)
+		if !ok {
+			return nil, fmt.Errorf("unknown parlour style %q", spec.Style)
+		}
+		s, ok := d.(Summarizer)
+		if !ok {
+			return nil, fmt.Errorf("parlour style %q cannot be summarized", spec.Style)
+		}
+		if _, err := RunSteps(d, DefaultSteps(), 0); err != nil {
+			return nil, err
+		}
+		return s.Summary(), nil
+	case "bedroom":
+		return decorateBedroom(CreateConcreteBedroomDecorator(spec.Style)), nil
+	case "kitchen":
+		return decorateKitchen(CreateConcreteKitchenDecorator(spec.Style)), nil
+	case "bathroom":
+		return decorateBathroom(CreateConcreteBathroomDecorator(spec.Style)), nil
+	}
+	return nil, fmt.Errorf("unknown room type %q", spec.Type)
+}
+
+// 卧室、厨房、卫生间的建造顺序
+func decorateBedroom(d BedroomDecorator) []*PartSummary {
+	d.BuildBed()
+	d.BuildWardrobe()
+	d.BuildLamp()
+	return d.Summary()
+}
+
+func decorateKitchen(d KitchenDecorator) []*PartSummary {
+	d.BuildCabinet()
+	d.BuildStove()
+	d.BuildFridge()
+	return d.Summary()
+}
+
+func decorateBathroom(d BathroomDecorator) []*PartSummary {
+	d.BuildTiles()
+	d.BuildShower()
+	d.BuildToilet()
+	return d.Summary()
+}
+
+func CreateHouseDirector(plan *FloorPlan) *HouseDirector {
+	return &HouseDirector{plan: plan}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+	流式建造者：每个步骤返回建造者本身，可以链式调用，最后由 Build() 返回完整的客厅。
+	Build() 检查必需的部件是否齐全、部件之间是否兼容（如电视要能挂在墙上），
+	任何一项不满足都不会返回半成品；建造完成的客厅不可再修改。
+*/
+
+const tvMargin = 20 // 电视两侧至少留出的墙面宽度，单位：厘米
+
+// 流式建造者：客厅
+type ParlourBuilder struct {
+	parlour Parlour
+}
+
+func (b *ParlourBuilder) Wall(wall string, width int) *ParlourBuilder {
+	b.parlour.wall, b.parlour.wallWidth = wall, width
+	return b
+}
+
+func (b *ParlourBuilder) TV(tv string, width int) *ParlourBuilder {
+	b.parlour.tv, b.parlour.tvWidth = tv, width
+	return b
+}
+
+func (b *ParlourBuilder) Sofa(sofa string, width int) *ParlourBuilder {
+	b.parlour.sofa, b.parlour.sofaWidth = sofa, width
+	return b
+}
+
+// 校验并返回不可修改的客厅；同一个部件设置多次时只检查最后一次的值
+func (b *ParlourBuilder) Build() (*Parlour, error) {
+	var errs []error
+	p := b.parlour
+	for _, part := range []struct {
+		part, name string
+		width      int
+	}{{"wall", p.wall, p.wallWidth}, {"tv", p.tv, p.tvWidth}, {"sofa", p.sofa, p.sofaWidth}} {
+		if part.name == "" {
+			errs = append(errs, fmt.Errorf("%s is required", part.part))
+		} else if part.width <= 0 {
+			errs = append(errs, fmt.Errorf("%s: width must be positive, got %d", part.part, part.width))
+		}
+	}
+	if p.wallWidth > 0 && p.tvWidth > 0 && p.tvWidth+2*tvMargin > p.wallWidth {
+		errs = append(errs, fmt.Errorf("tv %s (%dcm) does not fit wall %s (%dcm)", p.tv, p.tvWidth, p.wall, p.wallWidth))
+	}
+	if p.wallWidth > 0 && p.sofaWidth > p.wallWidth {
+		errs = append(errs, fmt.Errorf("sofa %s (%dcm) is wider than wall %s (%dcm)", p.sofa, p.sofaWidth, p.wall, p.wallWidth))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	p.frozen = true
+	return &p, nil
+}
+
+func CreateParlourBuilder() *ParlourBuilder {
+	return &ParlourBuilder{}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// 同一个部件设置多次时，以最后一次为准
+func TestParlourBuilderLastCallWins(t *testing.T) {
+	p, err := CreateParlourBuilder().
+		Wall("w", 0).Wall("w", 400).
+		TV("tv", 180).
+		Sofa("sofa", -1).Sofa("sofa", 300).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if p.wallWidth != 400 || p.sofaWidth != 300 {
+		t.Fatalf("widths = wall %d sofa %d, want 400 and 300", p.wallWidth, p.sofaWidth)
+	}
+
+	_, err = CreateParlourBuilder().Wall("w", 400).Wall("w", 0).TV("tv", 180).Sofa("sofa", 300).Build()
+	if err == nil || !strings.Contains(err.Error(), "wall: width must be positive, got 0") {
+		t.Fatalf("Build() = %v, want a wall width error", err)
+	}
+}
+
+func TestParlourBuilderErrors(t *testing.T) {
+	tests := []struct {
+		b    *ParlourBuilder
+		want []string
+	}{
+		{CreateParlourBuilder().Wall("w", 400), []string{"tv is required", "sofa is required"}},
+		{CreateParlourBuilder().Wall("w", 400).TV("tv", 380).Sofa("sofa", 500), []string{"does not fit wall", "is wider than wall"}},
+		{CreateParlourBuilder().Wall("w", 400).TV("tv", -5).Sofa("sofa", 300), []string{"tv: width must be positive, got -5"}},
+	}
+	for i, tt := range tests {
+		_, err := tt.b.Build()
+		if err == nil {
+			t.Errorf("case %d: Build() succeeded", i)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("case %d: Build() = %v, want %q", i, err, want)
+			}
+		}
+	}
+}
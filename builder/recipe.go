@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+/*
+	装修配方：项目经理不再写死建造顺序，而是执行从 JSON 文件加载的配方。
+	配方指定装修工人的名称（在注册表中查找具体建造者）、配方变量以及步骤列表，
+	每个步骤可以带参数（部件名称、宽度）、执行条件（某个变量等于或不等于某个值）以及依赖的步骤。
+	步骤的 id 默认为步骤名称，同一个步骤出现多次时需要指定不同的 id；依赖的步骤因条件不满足而不执行时，该依赖被忽略。
+	配方在任何步骤执行之前完成校验，不合法的配方不会执行任何步骤。
+*/
+
+// 装修工人注册表
+var decorators = map[string]func() Decorator{
+	"decorator1": func() Decorator { return CreateConcreteDecorator1() },
+	"decorator2": func() Decorator { return CreateConcreteDecorator2() },
+}
+
+// 注册装修工人，同名的会被替换
+func RegisterDecorator(name string, create func() Decorator) {
+	decorators[name] = create
+}
+
+func LookupDecorator(name string) (Decorator, bool) {
+	create, ok := decorators[name]
+	if !ok {
+		return nil, false
+	}
+	return create(), true
+}
+
+// 配方中的步骤名称及其对应的建造方法
+var recipeSteps = map[string]func(d Decorator){
+	"wall": Decorator.BuildWall,
+	"tv":   Decorator.BuildTV,
+	"sofa": Decorator.BuildSofa,
+}
+
+// 配方
+type Recipe struct {
+	Name      string            `json:"name"`
+	Decorator string            `json:"decorator"`
+	Vars      map[string]string `json:"vars,omitempty"`
+	Steps     []*RecipeStep     `json:"steps"`
+}
+
+// 配方步骤
+type RecipeStep struct {
+	ID        string      `json:"id,omitempty"`
+	Step      string      `json:"step"`
+	Params    *PartParams `json:"params,omitempty"`
+	When      *Condition  `json:"when,omitempty"`
+	DependsOn []string    `json:"depends_on,omitempty"`
+}
+
+func (s *RecipeStep) id() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return s.Step
+}
+
+// 步骤参数：部件名称和宽度，需要装修工人实现 PartDecorator
+type PartParams struct {
+	Name  string `json:"name"`
+	Width int    `json:"width"`
+}
+
+// 执行条件：变量等于 Equals，或者不等于 NotEquals
+type Condition struct {
+	Var       string  `json:"var"`
+	Equals    *string `json:"equals,omitempty"`
+	NotEquals *string `json:"not_equals,omitempty"`
+}
+
+func (c *Condition) Match(vars map[string]string) bool {
+	v := vars[c.Var]
+	if c.Equals != nil && v != *c.Equals {
+		return false
+	}
+	if c.NotEquals != nil && v == *c.NotEquals {
+		return false
+	}
+	return true
+}
+
+// 校验配方，返回所有错误
+func (r *Recipe) Validate() error {
+	var errs []error
+	d, ok := LookupDecorator(r.Decorator)
+	if !ok {
+		errs = append(errs, fmt.Errorf("unknown decorator %q", r.Decorator))
+	}
+	if len(r.Steps) == 0 {
+		errs = append(errs, errors.New("recipe has no steps"))
+	}
+	for i, step := range r.Steps {
+		if err := r.validateStep(step, d); err != nil {
+			errs = append(errs, fmt.Errorf("step %d (%s): %w", i+1, step.id(), err))
+		}
+	}
+	// 依赖关系和部件冲突按所有步骤检查，与条件无关
+	graph := make([]*Step, 0, len(r.Steps))
+	for _, step := range r.Steps {
+		graph = append(graph, &Step{Name: step.id(), Part: step.Step, DependsOn: step.DependsOn})
+	}
+	if err := CheckSteps(graph); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Recipe) validateStep(step *RecipeStep, d Decorator) error {
+	var errs []error
+	if _, ok := recipeSteps[step.Step]; !ok {
+		errs = append(errs, fmt.Errorf("unknown step, want one of %v", recipeStepNames()))
+	}
+	if p := step.Params; p != nil {
+		if p.Name == "" {
+			errs = append(errs, errors.New("params.name is required"))
+		}
+		if p.Width <= 0 {
+			errs = append(errs, fmt.Errorf("params.width must be positive, got %d", p.Width))
+		}
+		if _, ok := d.(PartDecorator); d != nil && !ok {
+			errs = append(errs, fmt.Errorf("decorator %q does not accept params", r.Decorator))
+		}
+	}
+	if c := step.When; c != nil {
+		if _, ok := r.Vars[c.Var]; !ok {
+			errs = append(errs, fmt.Errorf("condition uses undeclared var %q", c.Var))
+		}
+		if c.Equals == nil && c.NotEquals == nil {
+			errs = append(errs, errors.New("condition needs equals or not_equals"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 按配方创建项目经理，overrides 覆盖配方中声明的变量
+func (r *Recipe) Compile(overrides map[string]string) (*ProjectManager, error) {
+	if err := r.Validate(); err != nil {
+		return nil, fmt.Errorf("recipe %q: %w", r.Name, err)
+	}
+	vars := map[string]string{}
+	for k, v := range r.Vars {
+		vars[k] = v
+	}
+	for k, v := range overrides {
+		if _, ok := vars[k]; !ok {
+			return nil, fmt.Errorf("recipe %q: unknown var %q", r.Name, k)
+		}
+		vars[k] = v
+	}
+
+	d, _ := LookupDecorator(r.Decorator)
+	index := map[string]*RecipeStep{}
+	included := map[string]bool{}
+	var selected []*RecipeStep
+	for _, rs := range r.Steps {
+		index[rs.id()] = rs
+		if rs.When != nil && !rs.When.Match(vars) {
+			continue
+		}
+		included[rs.id()] = true
+		selected = append(selected, rs)
+	}
+	// 依赖的步骤不满足条件时，改为依赖它所依赖的步骤，保持建造同一部件的步骤之间的顺序
+	var deps func(rs *RecipeStep, seen map[string]bool) []string
+	deps = func(rs *RecipeStep, seen map[string]bool) []string {
+		var names []string
+		for _, dep := range rs.DependsOn {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			if included[dep] {
+				names = append(names, dep)
+			} else {
+				names = append(names, deps(index[dep], seen)...)
+			}
+		}
+		return names
+	}
+	var steps []*Step
+	for _, rs := range selected {
+		step := compileStep(rs)
+		step.DependsOn = deps(rs, map[string]bool{})
+		steps = append(steps, step)
+	}
+	m := CreateProjectManager(d)
+	m.SetSteps(steps...)
+	return m, nil
+}
+
+func compileStep(rs *RecipeStep) *Step {
+	part, params := rs.Step, rs.Params
+	if params == nil {
+		build := recipeSteps[part]
+		return &Step{Name: rs.id(), Part: part, Undo: UndoPart(part), Run: func(d Decorator) error {
+			build(d)
+			return nil
+		}}
+	}
+	return &Step{Name: rs.id(), Part: part, Undo: UndoPart(part), Run: func(d Decorator) error {
+		return d.(PartDecorator).BuildPart(part, params.Name, params.Width)
+	}}
+}
+
+func recipeStepNames() []string {
+	names := make([]string, 0, len(recipeSteps))
+	for name := range recipeSteps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 读取 JSON 配方，不认识的字段视为错误
+func ParseRecipe(r io.Reader) (*Recipe, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	recipe := &Recipe{}
+	if err := dec.Decode(recipe); err != nil {
+		return nil, fmt.Errorf("parse recipe: %w", err)
+	}
+	return recipe, nil
+}
+
+func LoadRecipe(path string) (*Recipe, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRecipe(f)
+}
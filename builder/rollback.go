@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+	回滚：装修到一半失败时，客厅不能停留在半成品状态。每个步骤可以登记补偿操作（Step.Undo），
+	后续步骤失败时，项目经理按完成顺序的相反顺序执行已完成步骤的补偿操作，
+	返回的错误同时列出失败的步骤和执行过的每一个补偿操作。
+	具体建造者实现 UndoDecorator 后，默认步骤和配方步骤会把部件恢复为建造前的值。
+*/
+
+// 部件状态
+type partState struct {
+	name  string
+	width int
+}
+
+// 恢复部件建造前的值
+func (p *Parlour) undoPart(part string) error {
+	if err := p.checkFrozen(); err != nil {
+		return err
+	}
+	n, w, history, err := p.fields(part)
+	if err != nil {
+		return err
+	}
+	if len(*history) == 0 {
+		return fmt.Errorf("nothing to undo for %s", part)
+	}
+	last := (*history)[len(*history)-1]
+	*history = (*history)[:len(*history)-1]
+	*n, *w = last.name, last.width
+	return nil
+}
+
+// 可以撤销部件的装修工人
+type UndoDecorator interface {
+	UndoPart(part string) error
+}
+
+func (c *ConcreteDecorator1) UndoPart(part string) error {
+	return c.parlour.undoPart(part)
+}
+
+func (c *ConcreteDecorator2) UndoPart(part string) error {
+	return c.parlour.undoPart(part)
+}
+
+var ErrNotUndoable = errors.New("decorator cannot undo parts")
+
+// 撤销部件的补偿操作
+func UndoPart(part string) func(d Decorator) error {
+	return func(d Decorator) error {
+		u, ok := d.(UndoDecorator)
+		if !ok {
+			return ErrNotUndoable
+		}
+		return u.UndoPart(part)
+	}
+}
+
+// 执行过的补偿操作
+type Compensation struct {
+	Step string
+	Err  error // 补偿操作本身的错误
+}
+
+func (c *Compensation) String() string {
+	if c.Err != nil {
+		return fmt.Sprintf("%s (failed: %v)", c.Step, c.Err)
+	}
+	return c.Step
+}
+
+// 按相反顺序执行已完成步骤的补偿操作，没有补偿操作的步骤被忽略
+func rollback(d Decorator, completed []*Step) []*Compensation {
+	var compensations []*Compensation
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Undo == nil {
+			continue
+		}
+		compensations = append(compensations, &Compensation{Step: step.Name, Err: step.Undo(d)})
+	}
+	return compensations
+}
+
+func compensationList(compensations []*Compensation) string {
+	items := make([]string, len(compensations))
+	for i, c := range compensations {
+		items[i] = c.String()
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 步骤按依赖链依次完成，最后一步失败后按相反顺序回滚
+func TestRollbackOrder(t *testing.T) {
+	var undone []string
+	undo := func(name string) func(d Decorator) error {
+		return func(d Decorator) error {
+			undone = append(undone, name)
+			if name == "paint" {
+				return errors.New("paint is dry")
+			}
+			return nil
+		}
+	}
+	steps := []*Step{
+		{Name: "wall", Run: func(d Decorator) error { return nil }, Undo: undo("wall")},
+		{Name: "paint", DependsOn: []string{"wall"}, Run: func(d Decorator) error { return nil }, Undo: undo("paint")},
+		{Name: "clean", DependsOn: []string{"paint"}, Run: func(d Decorator) error { return nil }}, // 没有补偿操作
+		{Name: "tv", DependsOn: []string{"clean"}, Run: func(d Decorator) error { return nil }, Undo: undo("tv")},
+		{Name: "inspect", DependsOn: []string{"tv"}, Run: func(d Decorator) error { return errors.New("crooked tv") }},
+	}
+	report, err := RunSteps(CreateConcreteDecorator1(), steps, 0)
+	if err == nil {
+		t.Fatal("RunSteps() succeeded")
+	}
+	if got := strings.Join(undone, ","); got != "tv,paint,wall" {
+		t.Fatalf("undo order %s, want tv,paint,wall", got)
+	}
+	if got := compensationList(report.RolledBack); got != "tv, paint (failed: paint is dry), wall" {
+		t.Errorf("rolled back %s", got)
+	}
+	if !strings.Contains(err.Error(), "rolled back: tv, paint (failed: paint is dry), wall") {
+		t.Errorf("error %q does not list the compensations", err)
+	}
+}
+
+// 默认步骤的补偿操作把部件恢复为建造前的值
+func TestRollbackRestoresParts(t *testing.T) {
+	d := CreateConcreteDecorator1()
+	// 项目开始之前已有的沙发
+	if err := d.BuildPart("sofa", "old sofa", 200); err != nil {
+		t.Fatal(err)
+	}
+	steps := append(DefaultSteps(), &Step{
+		Name:      "inspect",
+		DependsOn: []string{"tv", "sofa"},
+		Run:       func(d Decorator) error { return errors.New("inspection failed") },
+	})
+	if _, err := RunSteps(d, steps, 0); err == nil {
+		t.Fatal("RunSteps() succeeded")
+	}
+	p := d.Result()
+	if p.wall != "" || p.tv != "" || p.sofa != "old sofa" || p.sofaWidth != 200 {
+		t.Fatalf("after rollback wall %q tv %q sofa %q, want only the old sofa", p.wall, p.tv, p.sofa)
+	}
+}
+
+// 不能撤销部件的装修工人返回 ErrNotUndoable
+func TestUndoPartNotUndoable(t *testing.T) {
+	if err := UndoPart("wall")(plainDecorator{}); !errors.Is(err, ErrNotUndoable) {
+		t.Fatalf("UndoPart() = %v, want ErrNotUndoable", err)
+	}
+}
+
+type plainDecorator struct{}
+
+func (plainDecorator) BuildWall() {}
+func (plainDecorator) BuildTV()   {}
+func (plainDecorator) BuildSofa() {}
+func (plainDecorator) Show()      {}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+/*
+	快照：客户保存的设计方案。客厅可以深拷贝，可以保存为 JSON 快照并重新加载，
+	两个客厅可以逐个部件比较差异；建造者可以以快照为模板开始建造，客户只需修改想改的部件。
+*/
+
+// 深拷贝，包括回滚历史和不可修改标记
+func (p *Parlour) Clone() *Parlour {
+	c := *p
+	c.wallHistory = append([]partState(nil), p.wallHistory...)
+	c.tvHistory = append([]partState(nil), p.tvHistory...)
+	c.sofaHistory = append([]partState(nil), p.sofaHistory...)
+	return &c
+}
+
+// 客厅快照
+type ParlourSnapshot struct {
+	Wall      string `json:"wall"`
+	WallWidth int    `json:"wall_width,omitempty"`
+	TV        string `json:"tv"`
+	TVWidth   int    `json:"tv_width,omitempty"`
+	Sofa      string `json:"sofa"`
+	SofaWidth int    `json:"sofa_width,omitempty"`
+}
+
+func (p *Parlour) Snapshot() *ParlourSnapshot {
+	return &ParlourSnapshot{
+		Wall: p.wall, WallWidth: p.wallWidth,
+		TV: p.tv, TVWidth: p.tvWidth,
+		Sofa: p.sofa, SofaWidth: p.sofaWidth,
+	}
+}
+
+// 由快照得到可以修改的客厅
+func (s *ParlourSnapshot) Parlour() *Parlour {
+	return &Parlour{
+		wall: s.Wall, wallWidth: s.WallWidth,
+		tv: s.TV, tvWidth: s.TVWidth,
+		sofa: s.Sofa, sofaWidth: s.SofaWidth,
+	}
+}
+
+func (s *ParlourSnapshot) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func LoadSnapshot(r io.Reader) (*ParlourSnapshot, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	s := &ParlourSnapshot{}
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// 部件差异
+type PartDiff struct {
+	Part string
+	Old  string
+	New  string
+}
+
+func (d *PartDiff) String() string {
+	return fmt.Sprintf("%s: %q -> %q", d.Part, d.Old, d.New)
+}
+
+// 逐个部件比较两个客厅，宽度的差异单独列出
+func DiffParlours(a, b *Parlour) []*PartDiff {
+	var diffs []*PartDiff
+	add := func(part, from, to string) {
+		if from != to {
+			diffs = append(diffs, &PartDiff{Part: part, Old: from, New: to})
+		}
+	}
+	for _, part := range []string{"wall", "tv", "sofa"} {
+		an, aw, _, _ := a.fields(part)
+		bn, bw, _, _ := b.fields(part)
+		add(part, *an, *bn)
+		add(part+".width", widthString(*aw), widthString(*bw))
+	}
+	return diffs
+}
+
+func widthString(width int) string {
+	if width == 0 {
+		return ""
+	}
+	return strconv.Itoa(width)
+}
+
+// 以快照为模板开始建造
+func CreateParlourBuilderFrom(s *ParlourSnapshot) *ParlourBuilder {
+	return &ParlourBuilder{parlour: *s.Parlour()}
+}
+
+func (c *ConcreteDecorator1) StartFrom(s *ParlourSnapshot) {
+	c.parlour = s.Parlour()
+}
+
+// 建造结果的副本
+func (c *ConcreteDecorator1) Result() *Parlour {
+	return c.parlour.Clone()
+}
+
+func (c *ConcreteDecorator2) StartFrom(s *ParlourSnapshot) {
+	c.parlour = s.Parlour()
+}
+
+func (c *ConcreteDecorator2) Result() *Parlour {
+	return c.parlour.Clone()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	p, err := CreateParlourBuilder().Wall("wall3", 400).TV("tv3", 180).Sofa("sofa3", 300).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := p.Snapshot().Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s, p.Snapshot()) {
+		t.Fatalf("loaded %+v, want %+v", s, p.Snapshot())
+	}
+	// 由快照得到的客厅可以修改，与原来的客厅没有差异
+	loaded := s.Parlour()
+	if loaded.frozen {
+		t.Error("parlour from a snapshot is frozen")
+	}
+	if diffs := DiffParlours(p, loaded); len(diffs) != 0 {
+		t.Errorf("diff after round trip = %v", diffs)
+	}
+}
+
+func TestLoadSnapshotRejectsUnknownFields(t *testing.T) {
+	_, err := LoadSnapshot(strings.NewReader(`{"wall": "wall3", "lamp": "lamp1"}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "lamp"`) {
+		t.Fatalf("LoadSnapshot() = %v, want an unknown field error", err)
+	}
+}
+
+func TestDiffParlours(t *testing.T) {
+	p, err := CreateParlourBuilder().Wall("wall3", 400).TV("tv3", 180).Sofa("sofa3", 300).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweaked, err := CreateParlourBuilderFrom(p.Snapshot()).TV("tv4", 200).Sofa("sofa3", 320).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range DiffParlours(p, tweaked) {
+		got = append(got, d.String())
+	}
+	want := []string{`tv: "tv3" -> "tv4"`, `tv.width: "180" -> "200"`, `sofa.width: "300" -> "320"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+
+	// 没有宽度的部件显示为空字符串
+	diffs := DiffParlours(&Parlour{}, p)
+	if len(diffs) != 6 || diffs[1].String() != `wall.width: "" -> "400"` {
+		t.Fatalf("diff from an empty parlour = %v", diffs)
+	}
+}
+
+// 深拷贝之后修改副本不影响原来的客厅
+func TestCloneIsDeep(t *testing.T) {
+	d := CreateConcreteDecorator1()
+	d.BuildWall()
+	c := d.Result()
+	if err := c.undoPart("wall"); err != nil {
+		t.Fatal(err)
+	}
+	if p := d.Result(); p.wall != "wall1" || len(p.wallHistory) != 1 {
+		t.Fatalf("original wall %q with %d history entries after undoing the clone", p.wall, len(p.wallHistory))
+	}
+}
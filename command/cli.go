@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+	子命令：不带参数时运行命令模式的示例，带子命令时运行对应的工具，所有子命令共用同一份代码。
+	用法：
+		go build -o restaurant ./command/*.go
+		./restaurant frontdesk -chefs 2 -queue 16
+		./restaurant simulate -chefs 2 -rate 20 -duration 8h -menu foodA:4m:3,breakfast:9m:1
+	frontdesk 是交互式点餐终端，从标准输入逐行读取前台命令，输入 help 查看语法和菜单。
+	simulate 按给定的客流、菜单和厨师数运行厨房模拟，输出吞吐量、队列长度、厨师利用率和等待时间。
+*/
+
+// 子命令：名字 -> 入口，参数不包括子命令本身
+var subcommands = map[string]func(args []string, in io.Reader, out io.Writer) error{
+	"frontdesk": runFrontDesk,
+	"simulate":  runSimulate,
+}
+
+// 运行子命令，args 为空或第一个参数不是子命令时返回 false
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := run(args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// 交互式前台，输入结束或输入 quit 后等待后厨做完
+func runFrontDesk(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("frontdesk", flag.ExitOnError)
+	chefs := fs.Int("chefs", 2, "后厨的厨师数")
+	queue := fs.Int("queue", 16, "每个优先级队列的容量")
+	fs.Parse(args)
+
+	kitchen := CreateKitchen(*chefs, *queue)
+	defer kitchen.Close()
+	w := CreateWaiter()
+	w.SetKitchen(kitchen)
+	w.SetTracker(CreateOrderTracker(nil))
+	desk, err := CreateFrontDesk(w, DefaultDishes())
+	if err != nil {
+		return err
+	}
+	return desk.RunREPL(in, out)
+}
+
+// 厨房模拟，菜单格式为 name:cooktime[:weight]，多个菜品用逗号分隔
+func runSimulate(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	chefs := fs.Int("chefs", 2, "厨师数")
+	rate := fs.Float64("rate", 20, "每小时平均到达的订单数")
+	duration := fs.Duration("duration", 8*time.Hour, "模拟时长")
+	menu := fs.String("menu", "foodA:4m:3,breakfast:9m:1", "菜单，格式为 name:cooktime[:weight]，多个菜品用逗号分隔")
+	seed := fs.Uint64("seed", 1, "随机数种子")
+	fs.Parse(args)
+
+	dishes, err := ParseSimMenu(*menu)
+	if err != nil {
+		return err
+	}
+	r, err := Simulate(SimConfig{Duration: *duration, Chefs: *chefs, ArrivalRate: *rate, Menu: dishes, Seed: *seed})
+	if err != nil {
+		return err
+	}
+	r.Print(out)
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+/*
+	宏命令：套餐。套餐里包含若干道菜（也可以包含另一个套餐），服务员像对待一道菜一样对待套餐。
+	套餐可以按顺序做，也可以同时做；撤销套餐要么全部撤销，要么一道也不撤销：
+	套餐里的每一道菜都必须实现 Undoable，只要有一道菜不能撤销（例如已经做好的套餐），整个套餐都不会撤销。
+*/
+
+// 套餐的做菜方式
+type ComboMode int
+
+const (
+	Sequential ComboMode = iota // 按顺序做
+	Parallel                    // 同时做
+)
+
+func (m ComboMode) String() string {
+	if m == Parallel {
+		return "parallel"
+	}
+	return "sequential"
+}
+
+var ErrCannotUndo = errors.New("food cannot be undone")
+
+// 可以预先检查能否撤销的命令，CanUndo 返回 true 时 Undo 不会失败
+type Undoable interface {
+	CanUndo() bool
+}
+
+// 组合命令：套餐
+type Combo struct {
+	name     string
+	mode     ComboMode
+	children []Food
+
+	mu     sync.Mutex
+	cooked bool
+}
+
+func (c *Combo) Name() string {
+	return c.name
+}
+
+func (c *Combo) Add(food Food) {
+	c.children = append(c.children, food)
+}
+
+func (c *Combo) Children() []Food {
+	return c.children
+}
+
+func (c *Combo) Cooking() {
+	fmt.Printf("Combo %s (%s):\n", c.name, c.mode)
+	if c.mode == Parallel {
+		var wg sync.WaitGroup
+		for _, f := range c.children {
+			wg.Add(1)
+			go func(f Food) {
+				defer wg.Done()
+				f.Cooking()
+			}(f)
+		}
+		wg.Wait()
+	} else {
+		for _, f := range c.children {
+			f.Cooking()
+		}
+	}
+	c.mu.Lock()
+	c.cooked = true
+	c.mu.Unlock()
+}
+
+// 做好的套餐，或者包含不能撤销（或没有实现 Undoable）的菜的套餐，不能撤销
+func (c *Combo) CanUndo() bool {
+	c.mu.Lock()
+	cooked := c.cooked
+	c.mu.Unlock()
+	if cooked {
+		return false
+	}
+	for _, f := range c.children {
+		if u, ok := f.(Undoable); !ok || !u.CanUndo() {
+			return false
+		}
+	}
+	return true
+}
+
+// 先检查每一道菜都能撤销，再按点菜的相反顺序撤销
+func (c *Combo) Undo() error {
+	if !c.CanUndo() {
+		return fmt.Errorf("combo %s: %w", c.name, ErrCannotUndo)
+	}
+	var errs []error
+	for i := len(c.children) - 1; i >= 0; i-- {
+		if err := c.children[i].Undo(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("combo %s: %w", c.name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// 有一道菜不能写入日志时，整个套餐都不写入日志
+func (c *Combo) TypeTag() string {
+	for _, f := range c.children {
+		if _, ok := typeTag(f); !ok {
+			return ""
+		}
+	}
+	return "Combo"
+}
+
+// 套餐写入日志时，每道菜带着自己的类型标签
+type comboJSON struct {
+	Name     string       `json:"name"`
+	Mode     ComboMode    `json:"mode"`
+	Children []foodRecord `json:"children"`
+}
+
+type foodRecord struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (c *Combo) MarshalJSON() ([]byte, error) {
+	v := comboJSON{Name: c.name, Mode: c.mode}
+	for _, f := range c.children {
+		tag, ok := typeTag(f)
+		if !ok {
+			return nil, fmt.Errorf("combo %s: food %T is not serializable", c.name, f)
+		}
+		payload, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		v.Children = append(v.Children, foodRecord{Type: tag, Payload: payload})
+	}
+	return json.Marshal(v)
+}
+
+func decodeCombo(payload json.RawMessage) (Food, error) {
+	var v comboJSON
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+	c := CreateCombo(v.Name, v.Mode)
+	for _, r := range v.Children {
+		decode, ok := foodTypes[r.Type]
+		if !ok {
+			return nil, fmt.Errorf("combo %s: unknown food type %q", v.Name, r.Type)
+		}
+		f, err := decode(r.Payload)
+		if err != nil {
+			return nil, err
+		}
+		c.Add(f)
+	}
+	return c, nil
+}
+
+func init() {
+	RegisterFoodType("Combo", decodeCombo)
+}
+
+func CreateCombo(name string, mode ComboMode, foods ...Food) *Combo {
+	return &Combo{name: name, mode: mode, children: foods}
+}
@@ -25,63 +25,181 @@
 
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 func main() {
+	if runSubcommand(os.Args[1:]) {
+		return
+	}
+
 	w := CreateWaiter()
 	f := CreateFoodA(&FoodAChef{})
 	w.SetFoods(f)
 	w.Cmd()
-}
 
-/*
-	客户去餐馆点餐，客户可向服务员选择以上早餐中的若干种，
-	服务员将客户的请求交给相关的厨师去做。这里的点餐相当于“命令”，服务员相当于“调用者”，厨师相当于“接收者”，所以用命令模式实现比较合适。
-*/
-
-// 调用者：服务员
-type Waiter struct {
-	foods []Food
-}
+	// 撤销与恢复：已经上桌的菜不能撤销
+	w.SetFoods(CreateFoodA(&FoodAChef{}))
+	if err := w.Undo(); err != nil {
+		fmt.Println(err)
+	}
+	if err := w.Redo(); err != nil {
+		fmt.Println(err)
+	}
+	w.Cmd()
+	if err := w.Undo(); err != nil {
+		fmt.Println(err)
+	}
 
-// 点餐
-func (w *Waiter) SetFoods(food Food) {
-	w.foods = append(w.foods, food)
-}
+	// 后厨：厨师协程池，VIP 订单优先
+	kitchen := CreateKitchen(2, 8)
+	defer kitchen.Close()
+	vip, err := kitchen.Submit(context.Background(), CreateFoodA(&FoodAChef{}), PriorityVIP)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	vip.Wait(context.Background())
+	w.SetKitchen(kitchen)
+	w.SetFoods(CreateFoodA(&FoodAChef{}))
+	w.Cmd()
 
-// 调用命令
-func (w *Waiter) Cmd() {
-	for _, v := range w.foods {
-		v.Cooking()
+	// 套餐：可以嵌套，撤销时全部撤销或者一道也不撤销
+	w = CreateWaiter()
+	drinks := CreateCombo("drinks", Parallel, CreateFoodA(&FoodAChef{}), CreateFoodA(&FoodAChef{}))
+	breakfast := CreateCombo("breakfast", Sequential, CreateFoodA(&FoodAChef{}), drinks)
+	w.SetFoods(breakfast)
+	if err := w.Undo(); err != nil {
+		fmt.Println(err)
+	}
+	w.Redo()
+	w.Cmd()
+	w.SetFoods(CreateCombo("family", Sequential, breakfast, CreateFoodA(&FoodAChef{})))
+	if err := w.Undo(); err != nil {
+		fmt.Println(err)
 	}
-}
 
-func CreateWaiter() *Waiter {
-	return &Waiter{[]Food{}}
-}
+	// 定时命令：假时钟前进时到点的任务才会执行
+	clock := CreateFakeClock(time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC))
+	sched := CreateScheduler(clock, nil)
+	sched.At(time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC), CreateFoodA(&FoodAChef{}))
+	sched.After(30*time.Minute, CreateFoodA(&FoodAChef{}))
+	prep, _ := sched.Every(20*time.Minute, CreateCombo("prep", Sequential, CreateFoodA(&FoodAChef{})))
+	for _, job := range sched.Pending() {
+		fmt.Printf("job %d at %s every %s\n", job.ID, job.Next.Format("15:04"), job.Interval)
+	}
+	clock.Advance(time.Hour)
+	sched.Cancel(prep)
+	fmt.Println("pending jobs:", len(sched.Pending()))
 
-// 抽象命令：食物
-type Food interface {
-	Cooking()
-}
+	// 订单跟踪：订阅状态变化，按状态查询订单
+	tracker := CreateOrderTracker(nil)
+	unsubscribe := tracker.Subscribe(func(c StateChange) {
+		fmt.Printf("order %d: %s -> %s\n", c.OrderID, c.From, c.To)
+	})
+	w = CreateWaiter()
+	w.SetTracker(tracker)
+	w.SetFoods(CreateFoodA(&FoodAChef{}))
+	w.Cmd()
+	w.SetKitchen(kitchen)
+	w.SetFoods(CreateFoodA(&FoodAChef{}))
+	w.Cmd()
+	unsubscribe()
+	for _, o := range tracker.Orders(OrderDone) {
+		fmt.Printf("order %d done in %d steps\n", o.ID, len(o.History))
+	}
 
-// 具体命令：食物A
-type FoodA struct {
-	foodAChef *FoodAChef
-}
+	// 中间件：日志在最外层，重复订单被拦截，失败重试，每次尝试限时
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "elapsed" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	w = CreateWaiter()
+	w.SetTracker(tracker)
+	w.SetMiddleware(Logging(logger), Idempotency(100), Retry(3, 10*time.Millisecond), Timeout(50*time.Millisecond))
+	attempts := 0
+	w.SetFoods(WithIdempotencyKey(CookFunc(func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("stove not ready (attempt %d)", attempts)
+		}
+		fmt.Println("Flaky food!")
+		return nil
+	}), "table-4-1"))
+	w.SetFoods(WithIdempotencyKey(CreateFoodA(&FoodAChef{}), "table-4-1"))
+	w.SetFoods(CookFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	w.Cmd()
+	fmt.Println("failed orders:", len(tracker.Orders(OrderFailed)), "skipped orders:", len(tracker.Orders(OrderSkipped)))
 
-func (f *FoodA) Cooking() {
-	f.foodAChef.Cooking()
-}
+	// 前台：把文本命令解析成食物命令，交互式的前台见 frontdesk 子命令
+	deskKitchen := CreateKitchen(2, 16)
+	w = CreateWaiter()
+	w.SetKitchen(deskKitchen)
+	w.SetTracker(CreateOrderTracker(nil))
+	desk, err := CreateFrontDesk(w, DefaultDishes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	desk.RunREPL(strings.NewReader("order table=4 foodA x2 priority=vip\norder table 4\ncancel 99\n"), os.Stdout)
+	deskKitchen.Close()
+	fmt.Println()
 
-func CreateFoodA(chef *FoodAChef) *FoodA {
-	return &FoodA{chef}
-}
+	// 模拟：同样的客流下，一个厨师和两个厨师的对比，可以调整参数的模拟见 simulate 子命令
+	for _, chefs := range []int{1, 2} {
+		r, err := Simulate(SimConfig{Duration: 8 * time.Hour, Chefs: chefs, ArrivalRate: 12, Menu: DefaultSimMenu, Seed: 1})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		r.Print(os.Stdout)
+	}
 
-// 接受者：食物A厨师
-type FoodAChef struct {
-}
+	// 命令日志：模拟点了两道菜后进程崩溃，重新启动时重放没有做完的菜
+	path := filepath.Join(os.TempDir(), "waiter.journal")
+	os.Remove(path)
+	defer os.Remove(path)
+	j, err := OpenJournal(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	j.Begin(CreateFoodA(&FoodAChef{}))
+	j.Begin(CreateCombo("lunch", Parallel, CreateFoodA(&FoodAChef{}), CreateFoodA(&FoodAChef{})))
+	j.Close()
 
-func (f *FoodAChef) Cooking() {
-	fmt.Println("Food A!")
+	j, err = OpenJournal(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer j.Close()
+	w = CreateWaiter()
+	w.SetJournal(j)
+	n, err := w.Recover()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("recovered orders:", n)
+	w.Cmd()
+	if err := j.Compact(); err != nil {
+		fmt.Println(err)
+	}
+	foods, _ := j.Pending()
+	fmt.Println("pending after compaction:", len(foods))
 }
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+	前台：服务员在终端里输入 "order table=4 foodA x2" 或 "cancel 17"，前台把它解析成命令对象交给服务员。
+	菜名通过菜品注册表找到对应的构造函数和厨师（接受者）；解析错误会说明正确的语法。
+	语法：
+		order table=<n> <dish> [x<count>] [<dish> [x<count>] ...] [priority=normal|takeaway|vip]
+		cancel <order-id>
+		status [<order-id>]
+		help
+*/
+
+const orderUsage = "order table=<n> <dish> [x<count>] [<dish> [x<count>] ...] [priority=normal|takeaway|vip]"
+
+var statementUsage = map[string]string{
+	"order":  orderUsage,
+	"cancel": "cancel <order-id>",
+	"status": "status [<order-id>]",
+	"help":   "help",
+}
+
+// 语句
+type Statement interface {
+	Verb() string
+}
+
+// 点餐语句
+type OrderStmt struct {
+	Table    int
+	Items    []OrderItem
+	Priority Priority
+}
+
+type OrderItem struct {
+	Dish  string
+	Count int
+}
+
+func (s *OrderStmt) Verb() string {
+	return "order"
+}
+
+// 取消语句
+type CancelStmt struct {
+	OrderID int
+}
+
+func (s *CancelStmt) Verb() string {
+	return "cancel"
+}
+
+// 查询语句，OrderID 为 0 时查询所有订单
+type StatusStmt struct {
+	OrderID int
+}
+
+func (s *StatusStmt) Verb() string {
+	return "status"
+}
+
+type HelpStmt struct{}
+
+func (s *HelpStmt) Verb() string {
+	return "help"
+}
+
+// 解析错误，Col 从 1 开始
+type ParseError struct {
+	Col   int
+	Msg   string
+	Usage string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("col %d: %s\nusage: %s", e.Col, e.Msg, e.Usage)
+}
+
+type token struct {
+	text string
+	col  int
+}
+
+func tokenize(line string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range line + " " {
+		if r == ' ' || r == '\t' {
+			if start >= 0 {
+				tokens = append(tokens, token{line[start:i], start + 1})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	return tokens
+}
+
+// 解析一行输入
+func Parse(line string) (Statement, error) {
+	tokens := tokenize(line)
+	if len(tokens) == 0 {
+		return nil, &ParseError{Col: 1, Msg: "empty input", Usage: allUsage()}
+	}
+	verb, args := tokens[0], tokens[1:]
+	usage, ok := statementUsage[strings.ToLower(verb.text)]
+	if !ok {
+		return nil, &ParseError{Col: verb.col, Msg: fmt.Sprintf("unknown command %q", verb.text), Usage: allUsage()}
+	}
+	fail := func(col int, format string, a ...any) error {
+		return &ParseError{Col: col, Msg: fmt.Sprintf(format, a...), Usage: usage}
+	}
+	end := len(line) + 1
+	switch strings.ToLower(verb.text) {
+	case "order":
+		return parseOrder(args, end, fail)
+	case "cancel":
+		if len(args) != 1 {
+			return nil, fail(end, "cancel takes exactly one order id")
+		}
+		id, err := strconv.Atoi(args[0].text)
+		if err != nil || id <= 0 {
+			return nil, fail(args[0].col, "order id must be a positive number, got %q", args[0].text)
+		}
+		return &CancelStmt{OrderID: id}, nil
+	case "status":
+		if len(args) > 1 {
+			return nil, fail(args[1].col, "unexpected %q", args[1].text)
+		}
+		if len(args) == 0 {
+			return &StatusStmt{}, nil
+		}
+		id, err := strconv.Atoi(args[0].text)
+		if err != nil || id <= 0 {
+			return nil, fail(args[0].col, "order id must be a positive number, got %q", args[0].text)
+		}
+		return &StatusStmt{OrderID: id}, nil
+	default:
+		if len(args) > 0 {
+			return nil, fail(args[0].col, "unexpected %q", args[0].text)
+		}
+		return &HelpStmt{}, nil
+	}
+}
+
+func parseOrder(args []token, end int, fail func(int, string, ...any) error) (Statement, error) {
+	if len(args) == 0 || !strings.HasPrefix(args[0].text, "table=") {
+		col := end
+		if len(args) > 0 {
+			col = args[0].col
+		}
+		return nil, fail(col, "order must start with table=<n>")
+	}
+	table, err := strconv.Atoi(strings.TrimPrefix(args[0].text, "table="))
+	if err != nil || table <= 0 {
+		return nil, fail(args[0].col, "table must be a positive number, got %q", args[0].text)
+	}
+	s := &OrderStmt{Table: table}
+	seenPriority := false
+	for _, t := range args[1:] {
+		switch {
+		case strings.HasPrefix(t.text, "priority="):
+			if seenPriority {
+				return nil, fail(t.col, "priority given twice")
+			}
+			p, ok := parsePriority(strings.TrimPrefix(t.text, "priority="))
+			if !ok {
+				return nil, fail(t.col, "unknown priority %q, want normal, takeaway or vip", t.text)
+			}
+			s.Priority, seenPriority = p, true
+		case strings.HasPrefix(t.text, "x") && len(t.text) > 1 && isDigits(t.text[1:]):
+			if len(s.Items) == 0 {
+				return nil, fail(t.col, "count %s must follow a dish", t.text)
+			}
+			n, err := strconv.Atoi(t.text[1:])
+			if err != nil || n <= 0 {
+				return nil, fail(t.col, "count must be positive, got %q", t.text)
+			}
+			s.Items[len(s.Items)-1].Count = n
+		case strings.Contains(t.text, "="):
+			return nil, fail(t.col, "unknown option %q", t.text)
+		default:
+			s.Items = append(s.Items, OrderItem{Dish: t.text, Count: 1})
+		}
+	}
+	if len(s.Items) == 0 {
+		return nil, fail(end, "order needs at least one dish")
+	}
+	return s, nil
+}
+
+func parsePriority(s string) (Priority, bool) {
+	for p := PriorityNormal; p < priorityCount; p++ {
+		if p.String() == s {
+			return p, true
+		}
+	}
+	return PriorityNormal, false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func allUsage() string {
+	verbs := make([]string, 0, len(statementUsage))
+	for v := range statementUsage {
+		verbs = append(verbs, v)
+	}
+	sort.Strings(verbs)
+	lines := make([]string, len(verbs))
+	for i, v := range verbs {
+		lines[i] = statementUsage[v]
+	}
+	return strings.Join(lines, "\n       ")
+}
+
+// 菜品注册表：菜名 -> 构造函数（包括对应的厨师）
+type DishRegistry struct {
+	dishes map[string]func() Food
+}
+
+func (r *DishRegistry) Register(name string, create func() Food) {
+	r.dishes[name] = create
+}
+
+func (r *DishRegistry) Create(name string) (Food, error) {
+	create, ok := r.dishes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dish %q, known dishes: %s", name, strings.Join(r.Names(), ", "))
+	}
+	return create(), nil
+}
+
+func (r *DishRegistry) Names() []string {
+	names := make([]string, 0, len(r.dishes))
+	for name := range r.dishes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func CreateDishRegistry() *DishRegistry {
+	return &DishRegistry{dishes: map[string]func() Food{}}
+}
+
+// 默认菜单
+func DefaultDishes() *DishRegistry {
+	r := CreateDishRegistry()
+	r.Register("foodA", func() Food {
+		return CreateFoodA(&FoodAChef{})
+	})
+	r.Register("breakfast", func() Food {
+		drinks := CreateCombo("drinks", Parallel, CreateFoodA(&FoodAChef{}), CreateFoodA(&FoodAChef{}))
+		return CreateCombo("breakfast", Sequential, CreateFoodA(&FoodAChef{}), drinks)
+	})
+	return r
+}
+
+// 前台：把语句交给服务员执行
+type FrontDesk struct {
+	mu      sync.Mutex
+	waiter  *Waiter
+	tracker *OrderTracker
+	dishes  *DishRegistry
+	tickets map[int]*Ticket // 订单号 -> 后厨的订单凭据
+	tables  map[int]int     // 订单号 -> 桌号
+}
+
+// 执行一条语句，返回给服务员看的结果
+func (d *FrontDesk) Execute(s Statement) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch s := s.(type) {
+	case *OrderStmt:
+		return d.order(s)
+	case *CancelStmt:
+		t, ok := d.tickets[s.OrderID]
+		if !ok {
+			return "", fmt.Errorf("order %d not found", s.OrderID)
+		}
+		if !t.Cancel() {
+			o, _ := d.tracker.Order(s.OrderID)
+			return "", fmt.Errorf("order %d is already %s", s.OrderID, o.State)
+		}
+		return fmt.Sprintf("order %d cancelled", s.OrderID), nil
+	case *StatusStmt:
+		return d.status(s.OrderID)
+	case *HelpStmt:
+		return "usage: " + allUsage() + "\ndishes: " + strings.Join(d.dishes.Names(), ", "), nil
+	}
+	return "", fmt.Errorf("unsupported statement %q", s.Verb())
+}
+
+func (d *FrontDesk) order(s *OrderStmt) (string, error) {
+	// 先检查所有菜名，避免只点了一半
+	var foods []Food
+	for _, item := range s.Items {
+		for i := 0; i < item.Count; i++ {
+			f, err := d.dishes.Create(item.Dish)
+			if err != nil {
+				return "", err
+			}
+			foods = append(foods, f)
+		}
+	}
+	for _, f := range foods {
+		d.waiter.SetFoods(f)
+	}
+	tickets, err := d.waiter.Dispatch(context.Background(), s.Priority)
+	ids := make([]string, 0, len(tickets))
+	for _, t := range tickets {
+		id := t.OrderID()
+		d.tickets[id] = t
+		d.tables[id] = s.Table
+		ids = append(ids, strconv.Itoa(id))
+	}
+	if err != nil {
+		// 没有交给后厨的菜不能留给下一张桌子
+		unsent, werr := d.waiter.Withdraw()
+		err = fmt.Errorf("table %d: accepted orders [%s], %d not sent: %w", s.Table, strings.Join(ids, " "), len(unsent), err)
+		return "", errors.Join(err, werr)
+	}
+	return fmt.Sprintf("table %d: orders %s", s.Table, strings.Join(ids, " ")), nil
+}
+
+func (d *FrontDesk) status(id int) (string, error) {
+	if id != 0 {
+		o, ok := d.tracker.Order(id)
+		if !ok {
+			return "", fmt.Errorf("order %d not found", id)
+		}
+		return d.describe(o), nil
+	}
+	var lines []string
+	for state := OrderQueued; state <= OrderSkipped; state++ {
+		for _, o := range d.tracker.Orders(state) {
+			lines = append(lines, d.describe(o))
+		}
+	}
+	if len(lines) == 0 {
+		return "no orders", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *FrontDesk) describe(o Order) string {
+	return fmt.Sprintf("order %d table %d %s: %s", o.ID, d.tables[o.ID], foodName(o.Food), o.State)
+}
+
+// 交互式前台：逐行读取输入，直到输入结束或 quit
+func (d *FrontDesk) RunREPL(in io.Reader, out io.Writer) error {
+	sc := bufio.NewScanner(in)
+	fmt.Fprint(out, "> ")
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "quit" || line == "exit":
+			return nil
+		case line == "":
+		default:
+			s, err := Parse(line)
+			if err == nil {
+				var result string
+				if result, err = d.Execute(s); err == nil {
+					fmt.Fprintln(out, result)
+				}
+			}
+			if err != nil {
+				fmt.Fprintln(out, "error:", err)
+			}
+		}
+		fmt.Fprint(out, "> ")
+	}
+	return sc.Err()
+}
+
+// 前台需要后厨和订单跟踪
+func CreateFrontDesk(w *Waiter, dishes *DishRegistry) (*FrontDesk, error) {
+	if w.kitchen == nil || w.tracker == nil {
+		return nil, errors.New("front desk needs a waiter with a kitchen and an order tracker")
+	}
+	if dishes == nil {
+		dishes = DefaultDishes()
+	}
+	return &FrontDesk{waiter: w, tracker: w.tracker, dishes: dishes, tickets: map[int]*Ticket{}, tables: map[int]int{}}, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Statement
+	}{
+		{"order table=4 foodA x2 priority=vip", &OrderStmt{Table: 4, Items: []OrderItem{{"foodA", 2}}, Priority: PriorityVIP}},
+		{"ORDER  table=1\tfoodA breakfast x3", &OrderStmt{Table: 1, Items: []OrderItem{{"foodA", 1}, {"breakfast", 3}}}},
+		{"order table=2 priority=takeaway foodA", &OrderStmt{Table: 2, Items: []OrderItem{{"foodA", 1}}, Priority: PriorityTakeaway}},
+		{"cancel 17", &CancelStmt{OrderID: 17}},
+		{"status", &StatusStmt{}},
+		{"status 3", &StatusStmt{OrderID: 3}},
+		{"  help  ", &HelpStmt{}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.line)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		line  string
+		col   int
+		msg   string
+		usage string // 为空时为所有语句的语法
+	}{
+		{"", 1, "empty input", ""},
+		{"serve 1", 1, `unknown command "serve"`, ""},
+		{"order table 4", 7, "order must start with table=<n>", orderUsage},
+		{"order", 6, "order must start with table=<n>", orderUsage},
+		{"order table=0 foodA", 7, `table must be a positive number, got "table=0"`, orderUsage},
+		{"order table=4", 14, "order needs at least one dish", orderUsage},
+		{"order table=4 x2 foodA", 15, "count x2 must follow a dish", orderUsage},
+		{"order table=4 foodA x0", 21, `count must be positive, got "x0"`, orderUsage},
+		{"order table=4 foodA priority=vip priority=normal", 34, "priority given twice", orderUsage},
+		{"order table=4 foodA priority=fast", 21, `unknown priority "priority=fast"`, orderUsage},
+		{"order table=4 foodA size=large", 21, `unknown option "size=large"`, orderUsage},
+		{"cancel", 7, "cancel takes exactly one order id", "cancel <order-id>"},
+		{"cancel abc", 8, `order id must be a positive number, got "abc"`, "cancel <order-id>"},
+		{"status 1 2", 10, `unexpected "2"`, "status [<order-id>]"},
+		{"help me", 6, `unexpected "me"`, "help"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.line)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse(%q) error = %v, want a *ParseError", tt.line, err)
+			continue
+		}
+		usage := tt.usage
+		if usage == "" {
+			usage = allUsage()
+		}
+		if pe.Col != tt.col || !strings.HasPrefix(pe.Msg, tt.msg) || pe.Usage != usage {
+			t.Errorf("Parse(%q) = col %d %q usage %q, want col %d %q usage %q", tt.line, pe.Col, pe.Msg, pe.Usage, tt.col, tt.msg, usage)
+		}
+	}
+}
+
+func TestParseErrorShowsUsage(t *testing.T) {
+	_, err := Parse("order table 4")
+	want := "col 7: order must start with table=<n>\nusage: " + orderUsage
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+}
+
+// 后厨满了没有交出去的菜不能算到下一张桌子
+func TestFrontDeskDropsUnsentFoods(t *testing.T) {
+	kitchen := CreateKitchen(1, 2)
+	defer kitchen.Close()
+
+	// 让唯一的厨师忙着，后厨队列只能再接两道菜
+	started, release := make(chan struct{}), make(chan struct{})
+	_, err := kitchen.Submit(context.Background(), CookFunc(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}), PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	w := CreateWaiter()
+	w.SetKitchen(kitchen)
+	w.SetTracker(CreateOrderTracker(nil))
+	desk, err := CreateFrontDesk(w, DefaultDishes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute := func(line string) (string, error) {
+		s, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desk.Execute(s)
+	}
+
+	if _, err := execute("order table=1 foodA x3"); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("order error = %v, want ErrQueueFull", err)
+	}
+	if len(desk.tickets) != 2 {
+		t.Fatalf("accepted %d orders, want 2", len(desk.tickets))
+	}
+	close(release)
+	for _, ticket := range desk.tickets {
+		if err := ticket.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := execute("order table=2 foodA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 订单 3 是后厨没有接的那道菜，已经标记为失败
+	if want := "table 2: orders 4"; got != want {
+		t.Fatalf("order = %q, want %q", got, want)
+	}
+	if o, _ := desk.tracker.Order(3); o.State != OrderFailed {
+		t.Errorf("order 3 is %s, want failed", o.State)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+/*
+	命令日志：进程崩溃时，还没做完的订单不能丢。每个命令在执行之前带着类型标签序列化后追加到本地日志文件，
+	执行完成后再追加一条完成记录；重新启动时，重放日志把没有完成的命令重新交给服务员。
+	每条记录一行，格式为 "校验和 JSON"，校验和为 JSON 的 CRC-32；
+	最后一行写到一半时（崩溃造成）被截掉，其他位置的损坏记录视为错误。
+	压缩日志会去掉所有已经完成的记录。
+	没有实现 Serializable 的命令（比如 CookFunc）照常执行，只是不写日志，崩溃后也不会重放。
+*/
+
+// 可以写入日志的命令：类型标签用于重放时找到对应的解码函数，标签为空时不能写入日志
+type Serializable interface {
+	TypeTag() string
+}
+
+// 命令的类型标签，不能写入日志的命令返回 false
+func typeTag(food Food) (string, bool) {
+	s, ok := food.(Serializable)
+	if !ok || s.TypeTag() == "" {
+		return "", false
+	}
+	return s.TypeTag(), true
+}
+
+// 命令类型注册表：类型标签 -> 解码函数
+var foodTypes = map[string]func(payload json.RawMessage) (Food, error){
+	"FoodA": func(payload json.RawMessage) (Food, error) {
+		return CreateFoodA(&FoodAChef{}), nil
+	},
+}
+
+// 注册命令类型，同名的会被替换
+func RegisterFoodType(tag string, decode func(payload json.RawMessage) (Food, error)) {
+	foodTypes[tag] = decode
+}
+
+func (f *FoodA) TypeTag() string {
+	return "FoodA"
+}
+
+// 日志记录
+type journalRecord struct {
+	Seq     uint64          `json:"seq"`
+	Op      string          `json:"op"` // begin 或 done
+	Type    string          `json:"type,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// 命令日志
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	nextSeq uint64
+	pending map[uint64]*journalRecord // 还没完成的命令
+}
+
+// 打开日志文件，不存在时创建
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{path: path, pending: map[uint64]*journalRecord{}, nextSeq: 1}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.f = f
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	offset, line := 0, 0
+	for offset < len(data) {
+		line++
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			// 崩溃时写到一半的最后一行
+			return os.Truncate(j.path, int64(offset))
+		}
+		rec, err := decodeRecord(data[offset : offset+end])
+		if err != nil {
+			return fmt.Errorf("journal %s line %d: %v", j.path, line, err)
+		}
+		j.apply(rec)
+		offset += end + 1
+	}
+	return nil
+}
+
+func (j *Journal) apply(rec *journalRecord) {
+	switch rec.Op {
+	case "begin":
+		j.pending[rec.Seq] = rec
+	case "done":
+		delete(j.pending, rec.Seq)
+	}
+	if rec.Seq >= j.nextSeq {
+		j.nextSeq = rec.Seq + 1
+	}
+}
+
+func encodeRecord(rec *journalRecord) ([]byte, error) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(body), body)), nil
+}
+
+func decodeRecord(line []byte) (*journalRecord, error) {
+	if len(line) < 10 || line[8] != ' ' {
+		return nil, fmt.Errorf("malformed record")
+	}
+	sum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("malformed checksum")
+	}
+	body := line[9:]
+	if crc32.ChecksumIEEE(body) != uint32(sum) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+	rec := &journalRecord{}
+	if err := json.Unmarshal(body, rec); err != nil {
+		return nil, err
+	}
+	if rec.Op != "begin" && rec.Op != "done" {
+		return nil, fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return rec, nil
+}
+
+func (j *Journal) write(rec *journalRecord) error {
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(line); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// 命令执行之前记录，返回记录的序号
+func (j *Journal) Begin(food Food) (uint64, error) {
+	tag, ok := typeTag(food)
+	if !ok {
+		return 0, fmt.Errorf("food %T is not serializable", food)
+	}
+	if _, ok := foodTypes[tag]; !ok {
+		return 0, fmt.Errorf("food type %q is not registered", tag)
+	}
+	payload, err := json.Marshal(food)
+	if err != nil {
+		return 0, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	rec := &journalRecord{Seq: j.nextSeq, Op: "begin", Type: tag, Payload: payload}
+	if err := j.write(rec); err != nil {
+		return 0, err
+	}
+	j.apply(rec)
+	return rec.Seq, nil
+}
+
+// 命令完成（或取消）之后记录，序号 0 表示没有写日志的命令
+func (j *Journal) Complete(seq uint64) error {
+	if seq == 0 {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.pending[seq]; !ok {
+		return fmt.Errorf("journal has no pending command %d", seq)
+	}
+	rec := &journalRecord{Seq: seq, Op: "done"}
+	if err := j.write(rec); err != nil {
+		return err
+	}
+	j.apply(rec)
+	return nil
+}
+
+// 还没完成的命令，按序号排列
+func (j *Journal) Pending() ([]*JournaledFood, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	seqs := make([]uint64, 0, len(j.pending))
+	for seq := range j.pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(a, b int) bool { return seqs[a] < seqs[b] })
+	foods := make([]*JournaledFood, 0, len(seqs))
+	for _, seq := range seqs {
+		rec := j.pending[seq]
+		decode, ok := foodTypes[rec.Type]
+		if !ok {
+			return nil, fmt.Errorf("journal command %d: unknown food type %q", seq, rec.Type)
+		}
+		food, err := decode(rec.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("journal command %d: %v", seq, err)
+		}
+		foods = append(foods, &JournaledFood{Food: food, seq: seq})
+	}
+	return foods, nil
+}
+
+// 压缩：只保留还没完成的命令，先写临时文件再替换
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	seqs := make([]uint64, 0, len(j.pending))
+	for seq := range j.pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(a, b int) bool { return seqs[a] < seqs[b] })
+
+	tmp := j.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, seq := range seqs {
+		line, err := encodeRecord(j.pending[seq])
+		if err == nil {
+			_, err = w.Write(line)
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := flushAndSync(w, f); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	j.f.Close()
+	j.f, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+func flushAndSync(w *bufio.Writer, f *os.File) error {
+	err := w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.f.Close()
+}
+
+// 从日志重放的命令：已经有执行前的记录，执行后只需补记完成
+type JournaledFood struct {
+	Food
+	seq uint64
+}
+
+// 已经在日志中的命令不再重复记录，不能序列化的命令不记录，返回序号 0
+func (j *Journal) begin(food Food) (uint64, error) {
+	if jf, ok := food.(*JournaledFood); ok {
+		return jf.seq, nil
+	}
+	if _, ok := typeTag(food); !ok {
+		return 0, nil
+	}
+	return j.Begin(food)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 可以写入日志的测试命令，做过的菜记在 journalCooked 里
+type journalFood struct {
+	Name string `json:"name"`
+}
+
+var journalCooked []string
+
+func (f *journalFood) Cooking() {
+	journalCooked = append(journalCooked, f.Name)
+}
+
+func (f *journalFood) Undo() error {
+	return nil
+}
+
+func (f *journalFood) TypeTag() string {
+	return "journalFood"
+}
+
+func init() {
+	RegisterFoodType("journalFood", func(payload json.RawMessage) (Food, error) {
+		f := &journalFood{}
+		return f, json.Unmarshal(payload, f)
+	})
+}
+
+func openTestJournal(t *testing.T, path string) *Journal {
+	t.Helper()
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+// 写入若干命令，返回它们的序号
+func beginAll(t *testing.T, j *Journal, names ...string) []uint64 {
+	t.Helper()
+	seqs := make([]uint64, len(names))
+	for i, name := range names {
+		seq, err := j.Begin(&journalFood{name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs[i] = seq
+	}
+	return seqs
+}
+
+func expectPending(t *testing.T, j *Journal, names ...string) {
+	t.Helper()
+	foods, err := j.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(foods))
+	for i, f := range foods {
+		got[i] = f.Food.(*journalFood).Name
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Fatalf("pending %v, want %v", got, names)
+	}
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.journal")
+	j := openTestJournal(t, path)
+	seqs := beginAll(t, j, "soup", "steak", "cake")
+	if err := j.Complete(seqs[0]); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// 重新启动：没有完成的命令按顺序重新点一遍，做完之后补记完成
+	j = openTestJournal(t, path)
+	expectPending(t, j, "steak", "cake")
+	w := CreateWaiter()
+	w.SetJournal(j)
+	if n, err := w.Recover(); err != nil || n != 2 {
+		t.Fatalf("Recover = %d, %v, want 2", n, err)
+	}
+	journalCooked = nil
+	w.Cmd()
+	if got := strings.Join(journalCooked, ","); got != "steak,cake" {
+		t.Fatalf("cooked %s, want steak,cake", got)
+	}
+	j.Close()
+
+	j = openTestJournal(t, path)
+	expectPending(t, j)
+	// 序号接着日志里最大的序号往下排
+	if seq := beginAll(t, j, "tea")[0]; seq != 4 {
+		t.Errorf("next seq = %d, want 4", seq)
+	}
+}
+
+// 撤回的重放命令补记完成，下次重放时不会再回来
+func TestWaiterWithdrawCompletesReplayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.journal")
+	j := openTestJournal(t, path)
+	beginAll(t, j, "soup")
+	w := CreateWaiter()
+	w.SetJournal(j)
+	if _, err := w.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	foods, err := w.Withdraw()
+	if err != nil || len(foods) != 1 {
+		t.Fatalf("Withdraw = %d foods, %v, want 1 food", len(foods), err)
+	}
+	j.Close()
+
+	expectPending(t, openTestJournal(t, path))
+}
+
+// 崩溃时写到一半的最后一行被截掉，之前的记录不受影响
+func TestJournalTruncatesTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.journal")
+	j := openTestJournal(t, path)
+	beginAll(t, j, "soup", "steak")
+	j.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`1234abcd {"seq":3,"op":"be`)
+	f.Close()
+
+	j = openTestJournal(t, path)
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != info.Size() {
+		t.Fatalf("journal size after open = %d, want %d", after.Size(), info.Size())
+	}
+	expectPending(t, j, "soup", "steak")
+	// 截掉之后接着写的记录可以正常读回来
+	beginAll(t, j, "cake")
+	j.Close()
+	expectPending(t, openTestJournal(t, path), "soup", "steak", "cake")
+}
+
+// 中间的记录损坏时不能悄悄跳过
+func TestJournalChecksumMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.journal")
+	j := openTestJournal(t, path)
+	beginAll(t, j, "soup", "steak")
+	j.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 改掉第一条记录的菜名，长度不变，校验和对不上
+	data = []byte(strings.Replace(string(data), "soup", "soap", 1))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenJournal(path)
+	if err == nil || !strings.Contains(err.Error(), "line 1: checksum mismatch") {
+		t.Fatalf("OpenJournal error = %v, want a checksum mismatch on line 1", err)
+	}
+}
+
+// 压缩之后只剩没有完成的命令，重新打开后的状态不变
+func TestJournalCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.journal")
+	j := openTestJournal(t, path)
+	seqs := beginAll(t, j, "soup", "steak", "cake")
+	for _, seq := range []uint64{seqs[0], seqs[2]} {
+		if err := j.Complete(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Fatalf("compacted journal has %d lines, want 1:\n%s", lines, data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	// 压缩之后日志还可以继续写
+	if err := j.Complete(seqs[1]); err != nil {
+		t.Fatal(err)
+	}
+	beginAll(t, j, "tea")
+	j.Close()
+
+	expectPending(t, openTestJournal(t, path), "tea")
+}
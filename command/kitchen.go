@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+/*
+	后厨：服务员不再在自己的线程里一道一道地做菜，而是把命令交给后厨。
+	后厨有若干个厨师协程和有界的订单队列，订单按优先级（VIP、外卖、普通）出队，
+	提交订单不会阻塞，返回的订单凭据可以等待完成，也可以取消；还没开始做的菜可以通过 context 取消。
+*/
+
+// 订单优先级
+type Priority int
+
+const (
+	PriorityNormal   Priority = iota // 普通
+	PriorityTakeaway                 // 外卖
+	PriorityVIP                      // VIP
+	priorityCount
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityTakeaway:
+		return "takeaway"
+	case PriorityVIP:
+		return "vip"
+	}
+	return "unknown"
+}
+
+var (
+	ErrQueueFull     = errors.New("kitchen queue is full")
+	ErrKitchenClosed = errors.New("kitchen is closed")
+	ErrCancelled     = errors.New("order cancelled")
+)
+
+// 订单凭据
+type Ticket struct {
+	food     Food
+	priority Priority
+	kitchen  *Kitchen
+	done     chan struct{}
+
+	mu      sync.Mutex
+	stop    func() bool // 停止监听提交时的 context
+	started bool
+	err     error
+}
+
+func (t *Ticket) Food() Food {
+	return t.food
+}
+
+func (t *Ticket) Priority() Priority {
+	return t.priority
+}
+
+// 完成或取消时关闭
+func (t *Ticket) Done() <-chan struct{} {
+	return t.done
+}
+
+// 等待订单完成，ctx 结束时返回 ctx 的错误，订单本身不受影响
+func (t *Ticket) Wait(ctx context.Context) error {
+	select {
+	case <-t.done:
+		return t.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 订单的结果：完成为 nil，取消为 ErrCancelled，做菜失败时为做菜的错误
+func (t *Ticket) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// 取消还没开始做的订单，已经开始做的菜不能取消；取消的订单立即离开队列，不再占用容量
+func (t *Ticket) Cancel() bool {
+	t.mu.Lock()
+	if t.started {
+		t.mu.Unlock()
+		return false
+	}
+	t.started = true
+	t.err = ErrCancelled
+	stop := t.stop
+	t.mu.Unlock()
+	// context 结束时触发的取消也会走到这里，此时 stop 什么都不做
+	if stop != nil {
+		stop()
+	}
+	// 先离开队列再通知等待的人，等到取消的人可以马上用空出来的位置
+	t.kitchen.remove(t)
+	close(t.done)
+	return true
+}
+
+// 厨师取到订单时调用，订单已取消时返回 false
+func (t *Ticket) start() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		return false
+	}
+	t.started = true
+	return true
+}
+
+func (t *Ticket) finish(err error) {
+	t.mu.Lock()
+	t.stop()
+	t.err = err
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// 后厨
+type Kitchen struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queues   [priorityCount][]*Ticket
+	capacity int // 每个优先级队列的容量
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// 提交订单，队列已满时立即返回 ErrQueueFull；ctx 在开始做菜之前结束时订单被取消
+func (k *Kitchen) Submit(ctx context.Context, food Food, priority Priority) (*Ticket, error) {
+	if priority < 0 || priority >= priorityCount {
+		priority = PriorityNormal
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		return nil, ErrKitchenClosed
+	}
+	if len(k.queues[priority]) >= k.capacity {
+		return nil, ErrQueueFull
+	}
+	t := &Ticket{food: food, priority: priority, kitchen: k, done: make(chan struct{})}
+	// 持有 t.mu 赋值：ctx 已经结束时，回调要等赋值之后才能取消订单
+	t.mu.Lock()
+	t.stop = context.AfterFunc(ctx, func() { t.Cancel() })
+	t.mu.Unlock()
+	k.queues[priority] = append(k.queues[priority], t)
+	k.cond.Signal()
+	return t, nil
+}
+
+// 各优先级队列中等待的订单数
+func (k *Kitchen) QueueLen(priority Priority) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.queues[priority])
+}
+
+// 把取消的订单移出队列
+func (k *Kitchen) remove(t *Ticket) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	q := k.queues[t.priority]
+	for i, qt := range q {
+		if qt == t {
+			k.queues[t.priority] = append(q[:i:i], q[i+1:]...)
+			return
+		}
+	}
+}
+
+// 停止接单，做完队列中的订单后返回
+func (k *Kitchen) Close() {
+	k.mu.Lock()
+	k.closed = true
+	k.cond.Broadcast()
+	k.mu.Unlock()
+	k.wg.Wait()
+}
+
+// 取出优先级最高的订单，后厨关闭且队列为空时返回 nil
+func (k *Kitchen) next() *Ticket {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for {
+		for p := priorityCount - 1; p >= 0; p-- {
+			if q := k.queues[p]; len(q) > 0 {
+				k.queues[p] = q[1:]
+				return q[0]
+			}
+		}
+		if k.closed {
+			return nil
+		}
+		k.cond.Wait()
+	}
+}
+
+// 厨师协程
+func (k *Kitchen) chef() {
+	defer k.wg.Done()
+	for t := k.next(); t != nil; t = k.next() {
+		if !t.start() {
+			continue
+		}
+		t.finish(cookFood(context.Background(), t.food))
+	}
+}
+
+func CreateKitchen(chefs, queueSize int) *Kitchen {
+	if chefs < 1 {
+		chefs = 1
+	}
+	k := &Kitchen{capacity: queueSize}
+	k.cond = sync.NewCond(&k.mu)
+	k.wg.Add(chefs)
+	for i := 0; i < chefs; i++ {
+		go k.chef()
+	}
+	return k
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 让后厨唯一的厨师忙着，返回让厨师继续的函数
+func blockChef(t *testing.T, k *Kitchen) (release func()) {
+	t.Helper()
+	started, done := make(chan struct{}), make(chan struct{})
+	_, err := k.Submit(context.Background(), CookFunc(func(ctx context.Context) error {
+		close(started)
+		<-done
+		return nil
+	}), PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return func() { close(done) }
+}
+
+// 取消的订单在 Done 关闭之前已经离开队列，等到取消之后马上可以往满了的队列里提交新的订单
+func TestKitchenCancelFreesQueue(t *testing.T) {
+	k := CreateKitchen(1, 1)
+	defer k.Close()
+	release := blockChef(t, k)
+	defer release()
+
+	ticket, err := k.Submit(context.Background(), &namedFood{"queued"}, PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Submit(context.Background(), &namedFood{"extra"}, PriorityNormal); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("submit into full queue error = %v, want ErrQueueFull", err)
+	}
+
+	// 拿着后厨的锁，订单离不开队列，Done 也不能关闭
+	k.mu.Lock()
+	go ticket.Cancel()
+	select {
+	case <-ticket.Done():
+		k.mu.Unlock()
+		t.Fatal("Done closed before the ticket left the queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	k.mu.Unlock()
+
+	if err := ticket.Wait(context.Background()); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("Wait = %v, want ErrCancelled", err)
+	}
+	if _, err := k.Submit(context.Background(), &namedFood{"next"}, PriorityNormal); err != nil {
+		t.Fatalf("submit after cancel: %v", err)
+	}
+}
+
+// 通过 context 取消的订单同样在 Done 关闭之前离开队列
+func TestKitchenContextCancelFreesQueue(t *testing.T) {
+	k := CreateKitchen(1, 1)
+	defer k.Close()
+	release := blockChef(t, k)
+	defer release()
+
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		ticket, err := k.Submit(ctx, &namedFood{"queued"}, PriorityNormal)
+		if err != nil {
+			t.Fatalf("round %d: %v", i, err)
+		}
+		cancel()
+		<-ticket.Done()
+		if ticket.Cancel() {
+			t.Fatalf("round %d: second Cancel returned true", i)
+		}
+		if n := k.QueueLen(PriorityNormal); n != 0 {
+			t.Fatalf("round %d: %d tickets still queued after cancel", i, n)
+		}
+	}
+}
+
+func TestKitchenCancelStartedOrder(t *testing.T) {
+	k := CreateKitchen(1, 1)
+	defer k.Close()
+	started, done := make(chan struct{}), make(chan struct{})
+	ticket, err := k.Submit(context.Background(), CookFunc(func(ctx context.Context) error {
+		close(started)
+		<-done
+		return nil
+	}), PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if ticket.Cancel() {
+		t.Error("Cancel of a started order returned true")
+	}
+	close(done)
+	if err := ticket.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+/*
+	命令中间件：Cooking 不能失败也不能超时，ContextFood 是可以返回错误、可以通过 context 取消的命令。
+	服务员对每个命令应用中间件管道：失败重试（退避时间逐次加倍）、单个命令超时、
+	幂等键（相同键的重复订单被拦截）和结构化日志。没有实现 ContextFood 的命令按不会失败处理。
+*/
+
+// 可以失败、可以取消的命令
+type ContextFood interface {
+	CookContext(ctx context.Context) error
+}
+
+// 带幂等键的命令，键为空时不去重
+type Idempotent interface {
+	IdempotencyKey() string
+}
+
+var ErrDuplicateOrder = errors.New("duplicate order")
+
+// 做菜：实现了 ContextFood 的命令调用 CookContext，否则调用 Cooking
+func cookFood(ctx context.Context, food Food) error {
+	if cf, ok := food.(ContextFood); ok {
+		return cf.CookContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	food.Cooking()
+	return nil
+}
+
+// 函数命令：临时的命令不必定义新的类型
+type CookFunc func(ctx context.Context) error
+
+func (f CookFunc) CookContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+func (f CookFunc) Cooking() {
+	if err := f(context.Background()); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (f CookFunc) Undo() error {
+	return nil
+}
+
+func (f CookFunc) CanUndo() bool {
+	return true
+}
+
+// 为命令加上幂等键
+func WithIdempotencyKey(food Food, key string) Food {
+	return &keyedFood{Food: food, key: key}
+}
+
+type keyedFood struct {
+	Food
+	key string
+}
+
+func (f *keyedFood) IdempotencyKey() string {
+	return f.key
+}
+
+func (f *keyedFood) CookContext(ctx context.Context) error {
+	return cookFood(ctx, f.Food)
+}
+
+// 日志记录的是原来的命令，被包装的命令不能序列化时返回空标签
+func (f *keyedFood) TypeTag() string {
+	tag, _ := typeTag(f.Food)
+	return tag
+}
+
+func (f *keyedFood) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Food)
+}
+
+func (f *keyedFood) CanUndo() bool {
+	u, ok := f.Food.(Undoable)
+	return ok && u.CanUndo()
+}
+
+// 处理一个命令
+type Handler func(ctx context.Context, food Food) error
+
+// 中间件
+type Middleware func(next Handler) Handler
+
+// 组装管道，第一个中间件在最外层
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// 失败后重试，最多执行 attempts 次，第 n 次重试前等待 backoff * 2^(n-1)；
+// 重复订单和 ctx 结束时不再重试
+func Retry(attempts int, backoff time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, food Food) error {
+			var err error
+			for i := 0; i < attempts || i == 0; i++ {
+				if i > 0 {
+					t := time.NewTimer(backoff << (i - 1))
+					select {
+					case <-t.C:
+					case <-ctx.Done():
+						t.Stop()
+						return errors.Join(err, ctx.Err())
+					}
+				}
+				err = next(ctx, food)
+				if err == nil || errors.Is(err, ErrDuplicateOrder) || ctx.Err() != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+}
+
+// 单个命令超时；不理会 context 的命令会在后台继续做完，但结果被丢弃
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, food Food) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				done <- next(ctx, food)
+			}()
+			select {
+			case err := <-done:
+				return err
+			case <-ctx.Done():
+				return fmt.Errorf("%T: %w", food, ctx.Err())
+			}
+		}
+	}
+}
+
+// 幂等：相同幂等键的命令只做一次，正在做时重复的订单等待它的结果；
+// 失败的命令不计入，可以重新下单。只记住最近做完的 size 个键，更早的键会被忘记
+func Idempotency(size int) Middleware {
+	if size < 1 {
+		size = 1
+	}
+	type entry struct {
+		done chan struct{}
+		err  error
+	}
+	var mu sync.Mutex
+	seen := map[string]*entry{}
+	var finished []string // 做完的键，按完成顺序排列
+	return func(next Handler) Handler {
+		return func(ctx context.Context, food Food) error {
+			k, ok := food.(Idempotent)
+			if !ok || k.IdempotencyKey() == "" {
+				return next(ctx, food)
+			}
+			key := k.IdempotencyKey()
+			var e *entry
+			for {
+				mu.Lock()
+				prev, dup := seen[key]
+				if !dup {
+					e = &entry{done: make(chan struct{})}
+					seen[key] = e
+					mu.Unlock()
+					break
+				}
+				mu.Unlock()
+				select {
+				case <-prev.done:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				if prev.err == nil {
+					return fmt.Errorf("key %q: %w", key, ErrDuplicateOrder)
+				}
+				// 之前的订单失败了，重新抢占这个键
+			}
+			e.err = errors.New("cooking did not finish")
+			defer func() {
+				mu.Lock()
+				if e.err != nil {
+					delete(seen, key)
+				} else if finished = append(finished, key); len(finished) > size {
+					delete(seen, finished[0])
+					finished = finished[1:]
+				}
+				mu.Unlock()
+				close(e.done)
+			}()
+			e.err = next(ctx, food)
+			return e.err
+		}
+	}
+}
+
+// 结构化日志：记录命令类型、幂等键、耗时和错误；被拦截的重复订单不算错误
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, food Food) error {
+			attrs := []any{slog.String("food", foodName(food))}
+			if k, ok := food.(Idempotent); ok && k.IdempotencyKey() != "" {
+				attrs = append(attrs, slog.String("key", k.IdempotencyKey()))
+			}
+			start := time.Now()
+			err := next(ctx, food)
+			attrs = append(attrs, slog.Duration("elapsed", time.Since(start)))
+			switch {
+			case errors.Is(err, ErrDuplicateOrder):
+				logger.InfoContext(ctx, "duplicate skipped", attrs...)
+			case err != nil:
+				logger.ErrorContext(ctx, "cooking failed", append(attrs, slog.Any("err", err))...)
+			default:
+				logger.InfoContext(ctx, "cooked", attrs...)
+			}
+			return err
+		}
+	}
+}
+
+func foodName(food Food) string {
+	if k, ok := food.(*keyedFood); ok {
+		return foodName(k.Food)
+	}
+	if tag, ok := typeTag(food); ok {
+		return tag
+	}
+	return fmt.Sprintf("%T", food)
+}
+
+// 上桌的命令：经过服务员的中间件管道，并记录订单状态
+type servedFood struct {
+	Food
+	waiter *Waiter
+	id     int
+}
+
+func (f *servedFood) CookContext(ctx context.Context) error {
+	return f.waiter.handle(ctx, f.id, f.Food)
+}
+
+func (f *servedFood) Cooking() {
+	if err := f.CookContext(context.Background()); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+	订单跟踪：每个交给服务员的命令都有一个订单号，订单依次经过 排队、制作中、完成/失败/取消/跳过 几个状态，
+	每次状态变化都记录时间。可以按订单号或状态查询订单，也可以订阅状态变化事件。
+*/
+
+// 订单状态
+type OrderState int
+
+const (
+	OrderQueued    OrderState = iota // 排队
+	OrderCooking                     // 制作中
+	OrderDone                        // 完成
+	OrderFailed                      // 失败
+	OrderCancelled                   // 取消
+	OrderSkipped                     // 重复订单，已经由相同幂等键的订单做过
+)
+
+func (s OrderState) String() string {
+	switch s {
+	case OrderQueued:
+		return "queued"
+	case OrderCooking:
+		return "cooking"
+	case OrderDone:
+		return "done"
+	case OrderFailed:
+		return "failed"
+	case OrderCancelled:
+		return "cancelled"
+	case OrderSkipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+// 完成、失败、取消和跳过之后状态不再变化
+func (s OrderState) Final() bool {
+	return s == OrderDone || s == OrderFailed || s == OrderCancelled || s == OrderSkipped
+}
+
+// 允许的状态变化
+var orderTransitions = map[OrderState][]OrderState{
+	OrderQueued:  {OrderCooking, OrderFailed, OrderCancelled},
+	OrderCooking: {OrderDone, OrderFailed, OrderSkipped},
+}
+
+var ErrInvalidTransition = errors.New("invalid order state transition")
+
+// 状态变化事件
+type StateChange struct {
+	OrderID int
+	From    OrderState
+	To      OrderState
+	At      time.Time
+	Err     error // 失败的原因
+}
+
+// 订单，查询返回的是副本
+type Order struct {
+	ID      int
+	Food    Food
+	State   OrderState
+	Err     error
+	Created time.Time
+	Updated time.Time
+	History []StateChange
+}
+
+// 订单跟踪
+type OrderTracker struct {
+	mu     sync.Mutex
+	clock  Clock
+	orders map[int]*Order
+	nextID int
+
+	notify sync.Mutex // 保护 subs，通知期间一直持有
+	subs   map[int]func(StateChange)
+	subID  int
+}
+
+// 新订单，状态为排队
+func (t *OrderTracker) Add(food Food) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	now := t.clock.Now()
+	t.orders[t.nextID] = &Order{ID: t.nextID, Food: food, State: OrderQueued, Created: now, Updated: now}
+	return t.nextID
+}
+
+// 改变订单状态，err 为失败的原因
+func (t *OrderTracker) Transition(id int, to OrderState, err error) error {
+	// 状态变化逐个进行，事件按发生的顺序通知
+	t.notify.Lock()
+	defer t.notify.Unlock()
+	t.mu.Lock()
+	o, ok := t.orders[id]
+	if !ok {
+		t.mu.Unlock()
+		return fmt.Errorf("order %d not found", id)
+	}
+	if !allowed(o.State, to) {
+		t.mu.Unlock()
+		return fmt.Errorf("order %d: %s -> %s: %w", id, o.State, to, ErrInvalidTransition)
+	}
+	change := StateChange{OrderID: id, From: o.State, To: to, At: t.clock.Now(), Err: err}
+	o.State, o.Err, o.Updated = to, err, change.At
+	o.History = append(o.History, change)
+	t.mu.Unlock()
+
+	ids := make([]int, 0, len(t.subs))
+	for sid := range t.subs {
+		ids = append(ids, sid)
+	}
+	sort.Ints(ids)
+	for _, sid := range ids {
+		t.subs[sid](change)
+	}
+	return nil
+}
+
+func allowed(from, to OrderState) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// 按订单号查询
+func (t *OrderTracker) Order(id int) (Order, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o, ok := t.orders[id]
+	if !ok {
+		return Order{}, false
+	}
+	return o.copy(), true
+}
+
+// 按状态查询，按订单号排序
+func (t *OrderTracker) Orders(state OrderState) []Order {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var orders []Order
+	for _, o := range t.orders {
+		if o.State == state {
+			orders = append(orders, o.copy())
+		}
+	}
+	sort.Slice(orders, func(a, b int) bool { return orders[a].ID < orders[b].ID })
+	return orders
+}
+
+func (o *Order) copy() Order {
+	c := *o
+	c.History = append([]StateChange(nil), o.History...)
+	return c
+}
+
+// 订阅状态变化事件，返回取消订阅的函数；回调中可以查询订单，但不能改变订单状态
+func (t *OrderTracker) Subscribe(fn func(StateChange)) func() {
+	t.notify.Lock()
+	defer t.notify.Unlock()
+	t.subID++
+	id := t.subID
+	t.subs[id] = fn
+	return func() {
+		t.notify.Lock()
+		defer t.notify.Unlock()
+		delete(t.subs, id)
+	}
+}
+
+// 做菜并记录状态，做菜失败或 panic 的订单标记为失败，被拦截的重复订单标记为跳过
+func (t *OrderTracker) cook(ctx context.Context, id int, food Food, run Handler) (err error) {
+	if err := t.Transition(id, OrderCooking, nil); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cooking panicked: %v", r)
+		}
+		switch {
+		case errors.Is(err, ErrDuplicateOrder):
+			t.Transition(id, OrderSkipped, err)
+		case err != nil:
+			t.Transition(id, OrderFailed, err)
+		default:
+			t.Transition(id, OrderDone, nil)
+		}
+	}()
+	return run(ctx, food)
+}
+
+func CreateOrderTracker(clock Clock) *OrderTracker {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &OrderTracker{clock: clock, orders: map[int]*Order{}, subs: map[int]func(StateChange){}}
+}
+
+// 订单凭据对应的订单号，服务员没有跟踪订单时为 0
+func (t *Ticket) OrderID() int {
+	if f, ok := t.food.(*servedFood); ok {
+		return f.id
+	}
+	return 0
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+	定时命令：备菜需要在指定时间做、延迟一段时间后做，或者每隔一段时间做一批。
+	调度器接受食物命令和时间选项，可以取消任务、列出等待中的任务。
+	时钟可以替换：FakeClock 只有调用 Advance 时时间才会前进，不需要真的等待。
+*/
+
+// 时钟
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// 定时器，Stop 在定时器已经触发或已经停止时返回 false
+type Timer interface {
+	Stop() bool
+}
+
+// 真实时钟
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// 假时钟
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	f     func()
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range c.timers {
+		if v == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, when: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// 时间前进 d，按时间顺序触发到期的定时器；定时器回调中新建的定时器到期时也会触发
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		var next *fakeTimer
+		idx := -1
+		for i, t := range c.timers {
+			if !t.when.After(target) && (next == nil || t.when.Before(next.when)) {
+				next, idx = t, i
+			}
+		}
+		if next == nil {
+			break
+		}
+		c.timers = append(c.timers[:idx], c.timers[idx+1:]...)
+		c.now = next.when
+		c.mu.Unlock()
+		next.f()
+		c.mu.Lock()
+	}
+	c.now = target
+	c.mu.Unlock()
+}
+
+func CreateFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+var ErrInvalidInterval = errors.New("interval must be positive")
+
+// 定时任务
+type Job struct {
+	ID       int
+	Food     Food
+	Next     time.Time     // 下一次执行的时间
+	Interval time.Duration // 重复间隔，0 表示只执行一次
+	Runs     int           // 已经执行的次数
+}
+
+type job struct {
+	Job
+	timer Timer
+}
+
+// 调度器
+type Scheduler struct {
+	mu     sync.Mutex
+	clock  Clock
+	run    func(Food)
+	jobs   map[int]*job
+	nextID int
+}
+
+// 在 t 时刻做菜，t 已经过去时立即做
+func (s *Scheduler) At(t time.Time, food Food) int {
+	return s.schedule(food, t, 0)
+}
+
+// 延迟 d 后做菜
+func (s *Scheduler) After(d time.Duration, food Food) int {
+	return s.schedule(food, s.clock.Now().Add(d), 0)
+}
+
+// 从现在起每隔 interval 做一次菜，直到取消
+func (s *Scheduler) Every(interval time.Duration, food Food) (int, error) {
+	if interval <= 0 {
+		return 0, ErrInvalidInterval
+	}
+	return s.schedule(food, s.clock.Now().Add(interval), interval), nil
+}
+
+func (s *Scheduler) schedule(food Food, next time.Time, interval time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	j := &job{Job: Job{ID: s.nextID, Food: food, Next: next, Interval: interval}}
+	s.jobs[j.ID] = j
+	s.arm(j)
+	return j.ID
+}
+
+// 按 j.Next 设置定时器，调用时持有锁
+func (s *Scheduler) arm(j *job) {
+	d := j.Next.Sub(s.clock.Now())
+	if d < 0 {
+		d = 0
+	}
+	j.timer = s.clock.AfterFunc(d, func() { s.fire(j) })
+}
+
+func (s *Scheduler) fire(j *job) {
+	s.mu.Lock()
+	if s.jobs[j.ID] != j {
+		// 已经取消
+		s.mu.Unlock()
+		return
+	}
+	j.Runs++
+	if j.Interval > 0 {
+		// 从计划时间起算，避免误差累积
+		j.Next = j.Next.Add(j.Interval)
+		s.arm(j)
+	} else {
+		delete(s.jobs, j.ID)
+	}
+	s.mu.Unlock()
+	s.run(j.Food)
+}
+
+// 取消任务，任务不存在时返回 false
+func (s *Scheduler) Cancel(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	j.timer.Stop()
+	delete(s.jobs, id)
+	return true
+}
+
+// 等待中的任务，按下一次执行的时间排序
+func (s *Scheduler) Pending() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.Job)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		if jobs[a].Next.Equal(jobs[b].Next) {
+			return jobs[a].ID < jobs[b].ID
+		}
+		return jobs[a].Next.Before(jobs[b].Next)
+	})
+	return jobs
+}
+
+// 取消所有任务
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, j := range s.jobs {
+		j.timer.Stop()
+		delete(s.jobs, id)
+	}
+}
+
+// run 为空时到点直接做菜，也可以把菜交给后厨
+func CreateScheduler(clock Clock, run func(Food)) *Scheduler {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	if run == nil {
+		run = func(f Food) { f.Cooking() }
+	}
+	return &Scheduler{clock: clock, run: run, jobs: map[int]*job{}}
+}
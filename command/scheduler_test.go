@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+
+type namedFood struct {
+	name string
+}
+
+func (f *namedFood) Cooking() {}
+
+func (f *namedFood) Undo() error {
+	return nil
+}
+
+// 一次执行：菜名和执行的时间
+type firing struct {
+	name string
+	at   time.Time
+}
+
+type schedulerTest struct {
+	clock  *FakeClock
+	s      *Scheduler
+	fired  []firing
+	onFire func(name string) // 在记录执行之后调用，可以为空
+}
+
+func newSchedulerTest() *schedulerTest {
+	st := &schedulerTest{clock: CreateFakeClock(testStart)}
+	st.s = CreateScheduler(st.clock, func(f Food) {
+		name := f.(*namedFood).name
+		st.fired = append(st.fired, firing{name, st.clock.Now()})
+		if st.onFire != nil {
+			st.onFire(name)
+		}
+	})
+	return st
+}
+
+func (st *schedulerTest) expect(t *testing.T, want ...firing) {
+	t.Helper()
+	if len(st.fired) != len(want) {
+		t.Fatalf("fired %v, want %v", st.fired, want)
+	}
+	for i := range want {
+		if st.fired[i].name != want[i].name || !st.fired[i].at.Equal(want[i].at) {
+			t.Fatalf("firing %d = %v, want %v", i, st.fired[i], want[i])
+		}
+	}
+}
+
+func (st *schedulerTest) expectPending(t *testing.T, ids ...int) {
+	t.Helper()
+	jobs := st.s.Pending()
+	got := make([]int, len(jobs))
+	for i, j := range jobs {
+		got[i] = j.ID
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("pending %v, want %v", got, ids)
+	}
+	for i := range ids {
+		if got[i] != ids[i] {
+			t.Fatalf("pending %v, want %v", got, ids)
+		}
+	}
+}
+
+func at(d time.Duration) time.Time {
+	return testStart.Add(d)
+}
+
+func TestSchedulerAtInPast(t *testing.T) {
+	st := newSchedulerTest()
+	st.s.At(testStart.Add(-time.Hour), &namedFood{"late"})
+	st.expect(t)
+	// 已经过去的时间立即到期，不需要时间前进
+	st.clock.Advance(0)
+	st.expect(t, firing{"late", testStart})
+	st.expectPending(t)
+}
+
+func TestSchedulerAt(t *testing.T) {
+	st := newSchedulerTest()
+	st.s.At(at(15*time.Minute), &namedFood{"prep"})
+	st.clock.Advance(14 * time.Minute)
+	st.expect(t)
+	st.clock.Advance(time.Hour)
+	st.expect(t, firing{"prep", at(15 * time.Minute)})
+}
+
+func TestSchedulerAfter(t *testing.T) {
+	st := newSchedulerTest()
+	st.clock.Advance(time.Minute)
+	id := st.s.After(5*time.Minute, &namedFood{"soup"})
+	st.expectPending(t, id)
+	st.clock.Advance(4 * time.Minute)
+	st.expect(t)
+	st.clock.Advance(time.Minute)
+	st.expect(t, firing{"soup", at(6 * time.Minute)})
+	st.expectPending(t)
+}
+
+func TestSchedulerEveryFiresRepeatedlyInOneAdvance(t *testing.T) {
+	st := newSchedulerTest()
+	if _, err := st.s.Every(0, &namedFood{"bad"}); !errors.Is(err, ErrInvalidInterval) {
+		t.Fatalf("Every(0) error = %v, want ErrInvalidInterval", err)
+	}
+	id, err := st.s.Every(10*time.Minute, &namedFood{"bread"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.clock.Advance(35 * time.Minute)
+	st.expect(t,
+		firing{"bread", at(10 * time.Minute)},
+		firing{"bread", at(20 * time.Minute)},
+		firing{"bread", at(30 * time.Minute)},
+	)
+	jobs := st.s.Pending()
+	if len(jobs) != 1 || jobs[0].ID != id || jobs[0].Runs != 3 || !jobs[0].Next.Equal(at(40*time.Minute)) {
+		t.Fatalf("pending = %+v, want job %d with 3 runs, next at %v", jobs, id, at(40*time.Minute))
+	}
+}
+
+func TestSchedulerCancelDuringRecurringRun(t *testing.T) {
+	st := newSchedulerTest()
+	id, err := st.s.Every(10*time.Minute, &namedFood{"bread"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.onFire = func(name string) {
+		if len(st.fired) == 2 && !st.s.Cancel(id) {
+			t.Error("Cancel during run returned false")
+		}
+	}
+	st.clock.Advance(time.Hour)
+	st.expect(t,
+		firing{"bread", at(10 * time.Minute)},
+		firing{"bread", at(20 * time.Minute)},
+	)
+	st.expectPending(t)
+	if st.s.Cancel(id) {
+		t.Error("second Cancel returned true")
+	}
+}
+
+func TestSchedulerPendingOrder(t *testing.T) {
+	st := newSchedulerTest()
+	a := st.s.After(30*time.Minute, &namedFood{"a"})
+	b := st.s.At(at(10*time.Minute), &namedFood{"b"})
+	c, err := st.s.Every(10*time.Minute, &namedFood{"c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := st.s.After(20*time.Minute, &namedFood{"d"})
+	// 按下一次执行的时间排序，时间相同时按任务号排序
+	st.expectPending(t, b, c, d, a)
+	st.clock.Advance(10 * time.Minute)
+	st.expectPending(t, c, d, a)
+	st.s.Cancel(d)
+	st.expectPending(t, c, a)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+	离散事件模拟：开新店之前估算后厨需要几个厨师。订单按泊松过程到达，菜品按权重随机选择，
+	服务员（调用者）在订单到达时把食物命令交给模拟的厨师（接受者），厨师在假时钟上花掉菜单配置的做菜时间，
+	订单跟踪记录每个订单的状态和时间。模拟使用假时钟，时间只在事件之间跳跃。
+	报告包括吞吐量、队列长度、厨师利用率和等待时间（从下单到开始做）的 p50/p95/p99。
+*/
+
+// 模拟的菜品
+type SimDish struct {
+	Name     string
+	CookTime time.Duration
+	Weight   float64 // 被点到的相对概率
+}
+
+// 模拟配置
+type SimConfig struct {
+	Duration    time.Duration // 模拟时长
+	Chefs       int
+	ArrivalRate float64 // 每小时平均到达的订单数
+	Menu        []SimDish
+	Seed        uint64
+}
+
+// 模拟报告
+type SimReport struct {
+	Duration    time.Duration
+	Chefs       int
+	Arrived     int
+	Completed   int
+	Throughput  float64 // 每小时完成的订单数
+	AvgQueue    float64 // 按时间加权的平均队列长度
+	MaxQueue    int
+	FinalQueue  int     // 结束时还在排队的订单
+	Utilization float64 // 厨师忙碌时间的占比
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+}
+
+func (r *SimReport) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "duration\t%s\n", r.Duration)
+	fmt.Fprintf(tw, "chefs\t%d\n", r.Chefs)
+	fmt.Fprintf(tw, "orders\t%d arrived, %d completed\n", r.Arrived, r.Completed)
+	fmt.Fprintf(tw, "throughput\t%.1f orders/hour\n", r.Throughput)
+	fmt.Fprintf(tw, "queue\tavg %.2f, max %d, at end %d\n", r.AvgQueue, r.MaxQueue, r.FinalQueue)
+	fmt.Fprintf(tw, "utilization\t%.1f%%\n", r.Utilization*100)
+	fmt.Fprintf(tw, "wait\tp50 %s, p95 %s, p99 %s\n", r.P50.Round(time.Second), r.P95.Round(time.Second), r.P99.Round(time.Second))
+	tw.Flush()
+}
+
+// 默认菜单
+var DefaultSimMenu = []SimDish{
+	{Name: "foodA", CookTime: 4 * time.Minute, Weight: 3},
+	{Name: "breakfast", CookTime: 9 * time.Minute, Weight: 1},
+}
+
+// 解析菜单，格式为 name:cooktime:weight，多个菜品用逗号分隔，weight 可以省略
+func ParseSimMenu(s string) ([]SimDish, error) {
+	var menu []SimDish
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("menu item %q: want name:cooktime[:weight]", item)
+		}
+		d := SimDish{Name: parts[0], Weight: 1}
+		var err error
+		if d.CookTime, err = time.ParseDuration(parts[1]); err != nil {
+			return nil, fmt.Errorf("menu item %q: %v", item, err)
+		}
+		if len(parts) == 3 {
+			if d.Weight, err = strconv.ParseFloat(parts[2], 64); err != nil {
+				return nil, fmt.Errorf("menu item %q: %v", item, err)
+			}
+		}
+		menu = append(menu, d)
+	}
+	return menu, nil
+}
+
+func (cfg *SimConfig) validate() error {
+	var errs []error
+	if cfg.Duration <= 0 {
+		errs = append(errs, errors.New("duration must be positive"))
+	}
+	if cfg.Chefs < 1 {
+		errs = append(errs, errors.New("need at least one chef"))
+	}
+	if cfg.ArrivalRate <= 0 {
+		errs = append(errs, errors.New("arrival rate must be positive"))
+	}
+	if len(cfg.Menu) == 0 {
+		errs = append(errs, errors.New("menu is empty"))
+	}
+	for _, d := range cfg.Menu {
+		if d.CookTime <= 0 || d.Weight <= 0 {
+			errs = append(errs, fmt.Errorf("dish %s: cook time and weight must be positive", d.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 具体命令：模拟的菜
+type SimFood struct {
+	dish *SimDish
+	chef *SimChef
+	id   int // 订单号，交给厨师之后才有
+}
+
+func (f *SimFood) Cooking() {
+	f.chef.Cooking(f)
+}
+
+func (f *SimFood) Undo() error {
+	return f.chef.Cancel(f)
+}
+
+func CreateSimFood(dish *SimDish, chef *SimChef) *SimFood {
+	return &SimFood{dish: dish, chef: chef}
+}
+
+// 接受者：模拟的厨师，若干个厨师共用一个队列，做菜不真正调用 Cooking，而是在假时钟上占用做菜时间
+type SimChef struct {
+	clock   *FakeClock
+	tracker *OrderTracker
+	chefs   int
+
+	queue    []*SimFood
+	free     int
+	done     int
+	last     time.Time     // 上一次队列或厨师状态变化的时间
+	queueSum float64       // 队列长度对时间的积分，单位：纳秒
+	busy     time.Duration // 厨师忙碌时间之和
+	maxQueue int
+}
+
+// 接单：登记订单，有空闲的厨师时马上开始做
+func (c *SimChef) Cooking(f *SimFood) {
+	c.account()
+	f.id = c.tracker.Add(f)
+	c.queue = append(c.queue, f)
+	if len(c.queue) > c.maxQueue {
+		c.maxQueue = len(c.queue)
+	}
+	c.dispatch()
+}
+
+// 取消还在排队的菜
+func (c *SimChef) Cancel(f *SimFood) error {
+	for i, q := range c.queue {
+		if q == f {
+			c.account()
+			c.queue = append(c.queue[:i:i], c.queue[i+1:]...)
+			return c.tracker.Transition(f.id, OrderCancelled, nil)
+		}
+	}
+	return ErrServed
+}
+
+// 空闲的厨师按先来后到接单
+func (c *SimChef) dispatch() {
+	for c.free > 0 && len(c.queue) > 0 {
+		f := c.queue[0]
+		c.queue = c.queue[1:]
+		c.free--
+		c.tracker.Transition(f.id, OrderCooking, nil)
+		c.clock.AfterFunc(f.dish.CookTime, func() {
+			c.account()
+			c.tracker.Transition(f.id, OrderDone, nil)
+			c.free++
+			c.done++
+			c.dispatch()
+		})
+	}
+}
+
+// 累计上一次状态变化以来的队列长度和忙碌时间
+func (c *SimChef) account() {
+	now := c.clock.Now()
+	d := now.Sub(c.last)
+	c.queueSum += float64(len(c.queue)) * float64(d)
+	c.busy += time.Duration(c.chefs-c.free) * d
+	c.last = now
+}
+
+func CreateSimChef(clock *FakeClock, tracker *OrderTracker, chefs int) *SimChef {
+	return &SimChef{clock: clock, tracker: tracker, chefs: chefs, free: chefs, last: clock.Now()}
+}
+
+type simulation struct {
+	cfg     *SimConfig
+	clock   *FakeClock
+	chef    *SimChef
+	tracker *OrderTracker
+	rng     *rand.Rand
+	total   float64 // 菜品权重之和
+	arrived int
+}
+
+// 运行模拟，菜单中的菜只需要名字和做菜时间，不必是前台菜单里的菜
+func Simulate(cfg SimConfig) (*SimReport, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	clock := CreateFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := &simulation{
+		cfg:     &cfg,
+		clock:   clock,
+		tracker: CreateOrderTracker(clock),
+		rng:     rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+	s.chef = CreateSimChef(clock, s.tracker, cfg.Chefs)
+	for _, d := range cfg.Menu {
+		s.total += d.Weight
+	}
+	s.scheduleArrival()
+	s.clock.Advance(cfg.Duration)
+	s.chef.account()
+	return s.report(), nil
+}
+
+// 下一个订单在指数分布的间隔后到达
+func (s *simulation) scheduleArrival() {
+	gap := time.Duration(s.rng.ExpFloat64() / s.cfg.ArrivalRate * float64(time.Hour))
+	s.clock.AfterFunc(gap, s.arrive)
+}
+
+// 订单到达：服务员点菜并把命令交给厨师，每个订单一个服务员，不会积累已经上桌的菜
+func (s *simulation) arrive() {
+	w := CreateWaiter()
+	w.SetFoods(CreateSimFood(s.pick(), s.chef))
+	w.Cmd()
+	s.arrived++
+	s.scheduleArrival()
+}
+
+func (s *simulation) pick() *SimDish {
+	r := s.rng.Float64() * s.total
+	for i := range s.cfg.Menu {
+		if r -= s.cfg.Menu[i].Weight; r < 0 {
+			return &s.cfg.Menu[i]
+		}
+	}
+	return &s.cfg.Menu[len(s.cfg.Menu)-1]
+}
+
+func (s *simulation) report() *SimReport {
+	hours := s.cfg.Duration.Hours()
+	c := s.chef
+	r := &SimReport{
+		Duration:    s.cfg.Duration,
+		Chefs:       s.cfg.Chefs,
+		Arrived:     s.arrived,
+		Completed:   c.done,
+		Throughput:  float64(c.done) / hours,
+		AvgQueue:    c.queueSum / float64(s.cfg.Duration),
+		MaxQueue:    c.maxQueue,
+		FinalQueue:  len(c.queue),
+		Utilization: float64(c.busy) / float64(s.cfg.Duration) / float64(s.cfg.Chefs),
+	}
+	// 等待时间：从下单到开始做，结束时还在排队的订单不计入
+	var waits []time.Duration
+	for _, state := range []OrderState{OrderCooking, OrderDone} {
+		for _, o := range s.tracker.Orders(state) {
+			waits = append(waits, o.History[0].At.Sub(o.Created))
+		}
+	}
+	sort.Slice(waits, func(a, b int) bool { return waits[a] < waits[b] })
+	r.P50, r.P95, r.P99 = percentile(waits, 0.50), percentile(waits, 0.95), percentile(waits, 0.99)
+	return r
+}
+
+// 最近秩法求百分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// 固定种子的模拟结果是确定的，数字与示例的输出一致
+func TestSimulateFixedSeed(t *testing.T) {
+	tests := []struct {
+		chefs       int
+		arrived     int
+		completed   int
+		throughput  float64
+		maxQueue    int
+		finalQueue  int
+		utilization float64
+		p50         time.Duration
+		p95         time.Duration
+		p99         time.Duration
+	}{
+		{1, 96, 85, 10.625, 12, 10, 0.914, 10*time.Minute + 5*time.Second, 57*time.Minute + 30*time.Second, time.Hour + 4*time.Minute + 38*time.Second},
+		{2, 96, 96, 12, 3, 0, 0.515, 0, 4*time.Minute + 39*time.Second, 8*time.Minute + 31*time.Second},
+	}
+	for _, tt := range tests {
+		r, err := Simulate(SimConfig{Duration: 8 * time.Hour, Chefs: tt.chefs, ArrivalRate: 12, Menu: DefaultSimMenu, Seed: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Arrived != tt.arrived || r.Completed != tt.completed || r.Throughput != tt.throughput {
+			t.Errorf("%d chefs: %d arrived, %d completed, %.3f/hour, want %d, %d, %.3f/hour",
+				tt.chefs, r.Arrived, r.Completed, r.Throughput, tt.arrived, tt.completed, tt.throughput)
+		}
+		if r.MaxQueue != tt.maxQueue || r.FinalQueue != tt.finalQueue {
+			t.Errorf("%d chefs: queue max %d at end %d, want %d and %d", tt.chefs, r.MaxQueue, r.FinalQueue, tt.maxQueue, tt.finalQueue)
+		}
+		if math.Abs(r.Utilization-tt.utilization) > 0.0005 {
+			t.Errorf("%d chefs: utilization %.4f, want %.3f", tt.chefs, r.Utilization, tt.utilization)
+		}
+		if r.P50.Round(time.Second) != tt.p50 || r.P95.Round(time.Second) != tt.p95 || r.P99.Round(time.Second) != tt.p99 {
+			t.Errorf("%d chefs: wait p50 %v p95 %v p99 %v, want %v %v %v", tt.chefs, r.P50, r.P95, r.P99, tt.p50, tt.p95, tt.p99)
+		}
+	}
+}
+
+// 模拟的菜不需要在前台菜单里
+func TestSimulateCustomMenu(t *testing.T) {
+	menu, err := ParseSimMenu("pizza:12m:1,salad:3m:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Simulate(SimConfig{Duration: time.Hour, Chefs: 2, ArrivalRate: 10, Menu: menu, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Arrived == 0 {
+		t.Error("no orders arrived")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	five := []time.Duration{1, 2, 3, 4, 5}
+	tests := []struct {
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{nil, 0.5, 0},
+		{[]time.Duration{7}, 0, 7},
+		{[]time.Duration{7}, 0.99, 7},
+		{five, 0, 1},
+		{five, 0.2, 1}, // 正好落在秩上时取这一个，不取下一个
+		{five, 0.21, 2},
+		{five, 0.5, 3},
+		{five, 0.99, 5},
+		{five, 1, 5},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+		}
+	}
+}
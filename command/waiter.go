@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+/*
+	客户去餐馆点餐，客户可向服务员选择以上早餐中的若干种，
+	服务员将客户的请求交给相关的厨师去做。这里的点餐相当于“命令”，服务员相当于“调用者”，厨师相当于“接收者”，所以用命令模式实现比较合适。
+*/
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrServed        = errors.New("food has already been served")
+)
+
+// 调用者：服务员
+type Waiter struct {
+	foods    []Food
+	served   int           // foods 中前 served 个已经上桌（交给后厨的也算）
+	undone   []Food        // 撤销的菜，用于恢复
+	kitchen  *Kitchen      // 为空时服务员自己依次做菜
+	journal  *Journal      // 为空时不记录日志
+	tracker  *OrderTracker // 为空时不跟踪订单
+	pipeline Handler       // 中间件管道，为空时直接做菜
+}
+
+// 设置订单跟踪，之后上桌的每道菜都会生成一个订单
+func (w *Waiter) SetTracker(t *OrderTracker) {
+	w.tracker = t
+}
+
+// 设置中间件管道，第一个中间件在最外层；不传参数时取消管道
+func (w *Waiter) SetMiddleware(mws ...Middleware) {
+	if len(mws) == 0 {
+		w.pipeline = nil
+		return
+	}
+	w.pipeline = Chain(cookFood, mws...)
+}
+
+// 上桌：登记订单，返回经过中间件管道、记录订单状态的命令和订单号
+func (w *Waiter) serve(food Food) (Food, int) {
+	if w.tracker == nil && w.pipeline == nil {
+		return food, 0
+	}
+	id := 0
+	if w.tracker != nil {
+		id = w.tracker.Add(food)
+	}
+	return &servedFood{Food: food, waiter: w, id: id}, id
+}
+
+// 按中间件管道做菜并记录订单状态
+func (w *Waiter) handle(ctx context.Context, id int, food Food) error {
+	run := cookFood
+	if w.pipeline != nil {
+		run = w.pipeline
+	}
+	if w.tracker == nil {
+		return run(ctx, food)
+	}
+	return w.tracker.cook(ctx, id, food, run)
+}
+
+// 设置命令日志，之后的命令执行前后都会记录
+func (w *Waiter) SetJournal(j *Journal) {
+	w.journal = j
+}
+
+// 重放日志：把没有完成的命令重新点一遍，返回重新点的数量
+func (w *Waiter) Recover() (int, error) {
+	if w.journal == nil {
+		return 0, errors.New("waiter has no journal")
+	}
+	foods, err := w.journal.Pending()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range foods {
+		w.SetFoods(f)
+	}
+	return len(foods), nil
+}
+
+// 设置后厨，之后的命令交给后厨的厨师执行
+func (w *Waiter) SetKitchen(k *Kitchen) {
+	w.kitchen = k
+}
+
+// 点餐，新点的菜会清空恢复记录
+func (w *Waiter) SetFoods(food Food) {
+	w.foods = append(w.foods, food)
+	w.undone = nil
+}
+
+// 调用命令，只做还没有上桌的菜；设置了后厨时等待后厨做完
+func (w *Waiter) Cmd() {
+	if w.kitchen == nil {
+		for _, v := range w.foods[w.served:] {
+			seq, err := w.begin(v)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			w.served++
+			if err := w.cook(v, seq); err != nil {
+				fmt.Println(err)
+			}
+		}
+		return
+	}
+	tickets, err := w.Dispatch(context.Background(), PriorityNormal)
+	if err != nil {
+		fmt.Println(err)
+	}
+	for _, t := range tickets {
+		if err := t.Wait(context.Background()); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// 把还没有上桌的菜交给后厨，不等待完成；队列已满时，已经提交的订单凭据和错误一起返回
+func (w *Waiter) Dispatch(ctx context.Context, priority Priority) ([]*Ticket, error) {
+	if w.kitchen == nil {
+		return nil, errors.New("waiter has no kitchen")
+	}
+	var tickets []*Ticket
+	for _, v := range w.foods[w.served:] {
+		t, err := w.submit(ctx, v, priority)
+		if err != nil {
+			return tickets, err
+		}
+		tickets = append(tickets, t)
+		w.served++
+	}
+	return tickets, nil
+}
+
+// 撤回还没有上桌的菜，比如后厨满了没有交出去的菜；撤回的菜不能恢复，
+// 从日志重放的菜补记完成，下次重放时不会再回来
+func (w *Waiter) Withdraw() ([]Food, error) {
+	foods := append([]Food(nil), w.foods[w.served:]...)
+	w.foods = w.foods[:w.served]
+	var errs []error
+	for _, f := range foods {
+		if jf, ok := f.(*JournaledFood); ok && w.journal != nil {
+			errs = append(errs, w.journal.Complete(jf.seq))
+		}
+	}
+	return foods, errors.Join(errs...)
+}
+
+// 执行之前记录日志，没有设置日志时返回 0
+func (w *Waiter) begin(food Food) (uint64, error) {
+	if w.journal == nil {
+		return 0, nil
+	}
+	return w.journal.begin(food)
+}
+
+// 自己做一道菜，做完之后补记日志；做菜失败的命令也算完成，不再重放
+func (w *Waiter) cook(food Food, seq uint64) error {
+	served, _ := w.serve(food)
+	err := cookFood(context.Background(), served)
+	if w.journal != nil {
+		err = errors.Join(err, w.journal.Complete(seq))
+	}
+	return err
+}
+
+// 把一道菜交给后厨，提交前记录日志，订单完成或取消后补记完成
+func (w *Waiter) submit(ctx context.Context, food Food, priority Priority) (*Ticket, error) {
+	seq, err := w.begin(food)
+	if err != nil {
+		return nil, err
+	}
+	served, id := w.serve(food)
+	t, err := w.kitchen.Submit(ctx, served, priority)
+	if err != nil {
+		// 后厨没有接单，新记录的命令不用再重放
+		if _, replayed := food.(*JournaledFood); w.journal != nil && !replayed {
+			w.journal.Complete(seq)
+		}
+		if w.tracker != nil {
+			w.tracker.Transition(id, OrderFailed, err)
+		}
+		return nil, err
+	}
+	if w.journal == nil && w.tracker == nil {
+		return t, nil
+	}
+	go func() {
+		<-t.Done()
+		if w.tracker != nil && errors.Is(t.Err(), ErrCancelled) {
+			w.tracker.Transition(id, OrderCancelled, nil)
+		}
+		if w.journal != nil {
+			if err := w.journal.Complete(seq); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+	return t, nil
+}
+
+// 撤销最近点的一道菜，已经上桌的菜不能撤销
+func (w *Waiter) Undo() error {
+	if len(w.foods) == 0 {
+		return ErrNothingToUndo
+	}
+	if len(w.foods) <= w.served {
+		return ErrServed
+	}
+	last := w.foods[len(w.foods)-1]
+	if err := last.Undo(); err != nil {
+		return err
+	}
+	w.foods = w.foods[:len(w.foods)-1]
+	// 撤销重放的命令时补记完成，恢复时作为新命令重新记录
+	if jf, ok := last.(*JournaledFood); ok {
+		if w.journal != nil {
+			if err := w.journal.Complete(jf.seq); err != nil {
+				return err
+			}
+		}
+		last = jf.Food
+	}
+	w.undone = append(w.undone, last)
+	return nil
+}
+
+// 恢复最近撤销的一道菜
+func (w *Waiter) Redo() error {
+	if len(w.undone) == 0 {
+		return ErrNothingToRedo
+	}
+	last := w.undone[len(w.undone)-1]
+	w.undone = w.undone[:len(w.undone)-1]
+	w.foods = append(w.foods, last)
+	return nil
+}
+
+func CreateWaiter() *Waiter {
+	return &Waiter{foods: []Food{}}
+}
+
+// 抽象命令：食物
+type Food interface {
+	Cooking()
+	Undo() error // 取消这道菜并通知厨师
+}
+
+// 具体命令：食物A
+type FoodA struct {
+	foodAChef *FoodAChef
+}
+
+func (f *FoodA) Cooking() {
+	f.foodAChef.Cooking()
+}
+
+func (f *FoodA) Undo() error {
+	f.foodAChef.Cancel()
+	return nil
+}
+
+// 还没做的食物A总能撤销
+func (f *FoodA) CanUndo() bool {
+	return true
+}
+
+func CreateFoodA(chef *FoodAChef) *FoodA {
+	return &FoodA{chef}
+}
+
+// 接受者：食物A厨师
+type FoodAChef struct {
+}
+
+func (f *FoodAChef) Cooking() {
+	fmt.Println("Food A!")
+}
+
+func (f *FoodAChef) Cancel() {
+	fmt.Println("Food A cancelled!")
+}
@@ -31,9 +31,39 @@ import "fmt"
 
 func main() {
 	m := &Original{}
-	changer := CreateChanger(m)
-	girl := CreateGirl(changer)
+	girl := CreateGirl(m)
 	girl.Display()
+
+	// 装饰器可以任意嵌套，先包装的在内层
+	fmt.Println()
+	CreateGirl(CreateSuccubus(m)).Display()
+	fmt.Println()
+	CreateSuccubus(CreateGirl(CreateSuccubus(m))).Display()
+
+	// 原身没有被修改
+	fmt.Println()
+	m.Display()
+
+	// 通用装饰链：日志必须在最外层，少女必须包在女妖外面
+	fmt.Println()
+	w, err := CreateChain(logged("morrigan"), girlLayer, succubusLayer).Apply(m)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(w, w.Layers())
+	w.Value.Display()
+	if _, err := CreateChain(succubusLayer, girlLayer, logged("morrigan")).Apply(m); err != nil {
+		fmt.Println(err)
+	}
+
+	// 函数类型
+	greet, err := CreateChain(greeterLayers()...).Apply(func(name string) string { return "hello " + name })
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(greet, greet.Value("morrigan"))
 }
 
 /*
@@ -48,18 +78,13 @@ type Morrigan interface {
 
 // 具体构件角色：原身
 type Original struct {
-	decorator string
-}
-
-func (o *Original) SetDecorator(d string) {
-	o.decorator = d
 }
 
 func (o *Original) Display() {
-	fmt.Println(o.decorator)
+	fmt.Println("原身：可爱少女")
 }
 
-// 抽象装饰角色：变形
+// 抽象装饰角色：变形，可以包装任何莫莉卡，包括另一个装饰；具体装饰角色内嵌变形
 type Changer struct {
 	morrigan Morrigan
 }
@@ -72,52 +97,41 @@ func (c *Changer) DisPlay() {
 	c.morrigan.Display()
 }
 
+// 变形本身也是莫莉卡
+func (c *Changer) Display() {
+	c.morrigan.Display()
+}
+
 func CreateChanger(m Morrigan) *Changer {
 	return &Changer{m}
 }
 
-// 具体装饰角色：女妖
+// 具体装饰角色：女妖，在被包装者的外面长出飞翼，不修改被包装者
 type Succubus struct {
-	changer *Changer
-}
-
-func (s *Succubus) SetChanger() {
-	o, ok := s.changer.morrigan.(*Original)
-	if !ok {
-		fmt.Println("s.changer.morrigan type is not *Original")
-		return
-	}
-	o.SetDecorator("女妖")
+	Changer
 }
 
 func (s *Succubus) Display() {
-	s.SetChanger()
-	s.changer.DisPlay()
+	fmt.Println("[女妖] 头顶及背部长出蝙蝠状飞翼")
+	s.Changer.Display()
+	fmt.Println("[女妖] 收起飞翼")
 }
 
-func CreateSuccubus(changer *Changer) *Succubus {
-	return &Succubus{changer}
+func CreateSuccubus(m Morrigan) *Succubus {
+	return &Succubus{Changer{m}}
 }
 
-// 具体装饰角色：少女
+// 具体装饰角色：少女，在被包装者的外面穿上漂亮外衣，不修改被包装者
 type Girl struct {
-	changer *Changer
-}
-
-func (s *Girl) SetChanger() {
-	o, ok := s.changer.morrigan.(*Original)
-	if !ok {
-		fmt.Println("s.changer.morrigan type is not *Original")
-		return
-	}
-	o.SetDecorator("少女")
+	Changer
 }
 
 func (s *Girl) Display() {
-	s.SetChanger()
-	s.changer.DisPlay()
+	fmt.Println("[少女] 穿上漂亮外衣")
+	s.Changer.Display()
+	fmt.Println("[少女] 脱下外衣")
 }
 
-func CreateGirl(changer *Changer) *Girl {
-	return &Girl{changer}
+func CreateGirl(m Morrigan) *Girl {
+	return &Girl{Changer{m}}
 }
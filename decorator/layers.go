@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+	通用装饰工具：把变形（Changer）的思路推广到任意单方法接口或函数类型。
+	具名装饰器把一个值包装成同类型的值，装饰链按顺序组装装饰器，第一个在最外层；
+	装饰器可以声明位置约束（例如日志必须在最外层）或者必须包在某些装饰器的外面，违反约束时组装失败。
+	同一个装饰器可以在链中出现多次，比如 succubus(girl(succubus(m)))，约束对每一次出现分别检查。
+	组装的结果记录了包装它的每一层，可以从外到内列出。
+*/
+
+// 装饰器在链中的位置约束
+type Position int
+
+const (
+	Anywhere  Position = iota // 任意位置
+	Outermost                 // 必须在最外层
+	Innermost                 // 必须在最内层
+)
+
+func (p Position) String() string {
+	switch p {
+	case Outermost:
+		return "outermost"
+	case Innermost:
+		return "innermost"
+	}
+	return "anywhere"
+}
+
+// 具名装饰器
+type Decorator[T any] struct {
+	Name     string
+	Wrap     func(T) T
+	Position Position
+	Around   []string // 每次出现时里层都要有这些装饰器，链中没有的装饰器不受约束
+}
+
+// 装饰链
+type Chain[T any] struct {
+	decorators []Decorator[T]
+}
+
+func (c *Chain[T]) Use(ds ...Decorator[T]) *Chain[T] {
+	c.decorators = append(c.decorators, ds...)
+	return c
+}
+
+// 装饰器的名字，从外到内
+func (c *Chain[T]) Names() []string {
+	names := make([]string, len(c.decorators))
+	for i, d := range c.decorators {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// 检查名字和位置约束，返回所有违反的约束；重复出现的装饰器每一层都要满足约束
+func (c *Chain[T]) Validate() error {
+	var errs []error
+	innermost := map[string]int{} // 名字 -> 最里面一次出现的层
+	last := len(c.decorators) - 1
+	for i, d := range c.decorators {
+		if d.Name == "" {
+			errs = append(errs, fmt.Errorf("decorator %d has no name", i+1))
+			continue
+		}
+		if d.Wrap == nil {
+			errs = append(errs, fmt.Errorf("decorator %s has no wrap function", d.Name))
+		}
+		innermost[d.Name] = i
+		if d.Position == Outermost && i != 0 || d.Position == Innermost && i != last {
+			errs = append(errs, fmt.Errorf("decorator %s must be %s, got layer %d of %d", d.Name, d.Position, i+1, last+1))
+		}
+	}
+	for i, d := range c.decorators {
+		for _, inner := range d.Around {
+			if j, ok := innermost[inner]; ok && j < i {
+				errs = append(errs, fmt.Errorf("decorator %s at layer %d must wrap %s", d.Name, i+1, inner))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 按链包装 v，第一个装饰器在最外层
+func (c *Chain[T]) Apply(v T) (*Wrapped[T], error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	w := &Wrapped[T]{Value: v, base: v, layers: c.Names()}
+	for i := len(c.decorators) - 1; i >= 0; i-- {
+		w.Value = c.decorators[i].Wrap(w.Value)
+	}
+	return w, nil
+}
+
+func CreateChain[T any](ds ...Decorator[T]) *Chain[T] {
+	return &Chain[T]{decorators: ds}
+}
+
+// 装饰后的值
+type Wrapped[T any] struct {
+	Value  T
+	base   T
+	layers []string
+}
+
+// 包装的每一层，从外到内
+func (w *Wrapped[T]) Layers() []string {
+	return append([]string(nil), w.layers...)
+}
+
+// 没有装饰的原值
+func (w *Wrapped[T]) Base() T {
+	return w.base
+}
+
+// 例如 logging(girl(succubus(*main.Original)))
+func (w *Wrapped[T]) String() string {
+	s := fmt.Sprintf("%T", w.base)
+	for i := len(w.layers) - 1; i >= 0; i-- {
+		s = w.layers[i] + "(" + s + ")"
+	}
+	return s
+}
+
+// 函数类型也可以装饰
+type Greeter func(name string) string
+
+// 以下装饰器用于演示
+func logged(name string) Decorator[Morrigan] {
+	return Decorator[Morrigan]{Name: "logging", Position: Outermost, Wrap: func(m Morrigan) Morrigan {
+		return MorriganFunc(func() {
+			fmt.Println("log: " + name + " begins")
+			m.Display()
+			fmt.Println("log: " + name + " ends")
+		})
+	}}
+}
+
+// 函数适配为莫莉卡
+type MorriganFunc func()
+
+func (f MorriganFunc) Display() {
+	f()
+}
+
+var (
+	succubusLayer = Decorator[Morrigan]{Name: "succubus", Wrap: func(m Morrigan) Morrigan {
+		return CreateSuccubus(m)
+	}}
+	girlLayer = Decorator[Morrigan]{Name: "girl", Around: []string{"succubus"}, Wrap: func(m Morrigan) Morrigan {
+		return CreateGirl(m)
+	}}
+)
+
+func greeterLayers() []Decorator[Greeter] {
+	return []Decorator[Greeter]{
+		{Name: "exclaim", Wrap: func(g Greeter) Greeter {
+			return func(name string) string { return g(name) + "!" }
+		}},
+		{Name: "upper", Position: Innermost, Wrap: func(g Greeter) Greeter {
+			return func(name string) string { return g(strings.ToUpper(name)) }
+		}},
+	}
+}
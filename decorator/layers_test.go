@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 只记录名字的装饰器，用于检查包装顺序
+func tagLayer(name string) Decorator[Greeter] {
+	return Decorator[Greeter]{Name: name, Wrap: func(g Greeter) Greeter {
+		return func(s string) string { return name + "(" + g(s) + ")" }
+	}}
+}
+
+func TestChainLayersOrder(t *testing.T) {
+	w, err := CreateChain(tagLayer("a"), tagLayer("b"), tagLayer("c")).Apply(func(s string) string { return s })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Layers(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Layers() = %v, want %v", got, want)
+	}
+	if got, want := w.String(), "a(b(c(main.Greeter)))"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	// 第一个装饰器在最外层
+	if got := w.Value("x"); got != "a(b(c(x)))" {
+		t.Errorf("Value(x) = %q, want a(b(c(x)))", got)
+	}
+	if got := w.Base()("x"); got != "x" {
+		t.Errorf("Base()(x) = %q, want x", got)
+	}
+	// 返回的是副本
+	w.Layers()[0] = "z"
+	if w.Layers()[0] != "a" {
+		t.Error("Layers() returned the internal slice")
+	}
+}
+
+func TestChainPositionViolations(t *testing.T) {
+	outer := Decorator[Greeter]{Name: "outer", Position: Outermost, Wrap: tagLayer("outer").Wrap}
+	inner := Decorator[Greeter]{Name: "inner", Position: Innermost, Wrap: tagLayer("inner").Wrap}
+	tests := []struct {
+		chain *Chain[Greeter]
+		want  []string
+	}{
+		{CreateChain(outer, tagLayer("a"), inner), nil},
+		{CreateChain(tagLayer("a"), outer), []string{"decorator outer must be outermost, got layer 2 of 2"}},
+		{CreateChain(inner, tagLayer("a")), []string{"decorator inner must be innermost, got layer 1 of 2"}},
+		{CreateChain(inner, outer), []string{"outer must be outermost", "inner must be innermost"}},
+		// 重复出现时每一层都要满足约束
+		{CreateChain(outer, tagLayer("a"), outer), []string{"decorator outer must be outermost, got layer 3 of 3"}},
+		{CreateChain(Decorator[Greeter]{Wrap: tagLayer("a").Wrap}, Decorator[Greeter]{Name: "b"}), []string{"decorator 1 has no name", "decorator b has no wrap function"}},
+	}
+	for i, tt := range tests {
+		err := tt.chain.Validate()
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("case %d: Validate() = %v", i, err)
+			}
+			continue
+		}
+		for _, want := range tt.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("case %d: Validate() = %v, want %q", i, err, want)
+			}
+		}
+	}
+}
+
+func TestChainAround(t *testing.T) {
+	tests := []struct {
+		layers []Decorator[Morrigan]
+		want   string // 为空时应该通过检查
+	}{
+		{[]Decorator[Morrigan]{girlLayer, succubusLayer}, ""},
+		{[]Decorator[Morrigan]{girlLayer}, ""}, // 链中没有女妖，不受约束
+		{[]Decorator[Morrigan]{succubusLayer, girlLayer}, "decorator girl at layer 2 must wrap succubus"},
+		// 同一个装饰器可以出现多次，只要每一个少女的里层都有女妖
+		{[]Decorator[Morrigan]{succubusLayer, girlLayer, succubusLayer}, ""},
+		{[]Decorator[Morrigan]{girlLayer, succubusLayer, girlLayer}, "decorator girl at layer 3 must wrap succubus"},
+	}
+	for _, tt := range tests {
+		chain := CreateChain(tt.layers...)
+		err := chain.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%v: Validate() = %v", chain.Names(), err)
+		case tt.want != "" && (err == nil || err.Error() != tt.want):
+			t.Errorf("%v: Validate() = %v, want %q", chain.Names(), err, tt.want)
+		}
+	}
+}
+
+func TestChainRepeatedLayers(t *testing.T) {
+	w, err := CreateChain(succubusLayer, girlLayer, succubusLayer).Apply(&Original{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.String(), "succubus(girl(succubus(*main.Original)))"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	outer, ok := w.Value.(*Succubus)
+	if !ok {
+		t.Fatalf("outermost layer is %T, want *Succubus", w.Value)
+	}
+	if _, ok := outer.morrigan.(*Girl); !ok {
+		t.Fatalf("second layer is %T, want *Girl", outer.morrigan)
+	}
+}